@@ -0,0 +1,270 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// repoWorkflow pairs a workflow with the repository it belongs to, for
+// computations that rank workflows across repositories.
+type repoWorkflow struct {
+	Repo     string
+	Workflow *workflow
+}
+
+func allWorkflows(repos []*repositoryData) []repoWorkflow {
+	out := []repoWorkflow{}
+	for _, r := range repos {
+		for _, w := range r.Workflows {
+			out = append(out, repoWorkflow{Repo: r.Name, Workflow: w})
+		}
+	}
+	return out
+}
+
+// workflowGroup is every repository's workflow of the same name, for
+// --group-by workflow.
+type workflowGroup struct {
+	Name      string
+	Workflows []repoWorkflow
+}
+
+// pivotByWorkflow pivots repos into a group per workflow name, ordered by
+// name ignoring case. Within a group, repositories keep the order of repos.
+func pivotByWorkflow(repos []*repositoryData) []workflowGroup {
+	groups := []workflowGroup{}
+	index := map[string]int{}
+	for _, rw := range allWorkflows(repos) {
+		i, ok := index[rw.Workflow.Name]
+		if !ok {
+			i = len(groups)
+			index[rw.Workflow.Name] = i
+			groups = append(groups, workflowGroup{Name: rw.Workflow.Name})
+		}
+		groups[i].Workflows = append(groups[i].Workflows, rw)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		return strings.ToLower(groups[i].Name) < strings.ToLower(groups[j].Name)
+	})
+
+	return groups
+}
+
+// failingWorkflows returns every workflow whose most recent completed run
+// failed.
+func failingWorkflows(repos []*repositoryData) []repoWorkflow {
+	out := []repoWorkflow{}
+	for _, rw := range allWorkflows(repos) {
+		if len(rw.Workflow.Runs) > 0 && rw.Workflow.Runs[0].failed() {
+			out = append(out, rw)
+		}
+	}
+	return out
+}
+
+// staleWorkflows returns every workflow that neither finished nor started a
+// run in the selected window. Disabled workflows are expected to be idle.
+func staleWorkflows(repos []*repositoryData) []repoWorkflow {
+	out := []repoWorkflow{}
+	for _, rw := range allWorkflows(repos) {
+		if len(rw.Workflow.Runs) == 0 && len(rw.Workflow.InProgress) == 0 && !rw.Workflow.Disabled {
+			out = append(out, rw)
+		}
+	}
+	return out
+}
+
+// topN keeps the n workflows with the largest nonzero score, ties broken by
+// repository and then workflow name.
+func topN(repos []*repositoryData, n int, score func(*workflow) int64) []repoWorkflow {
+	out := []repoWorkflow{}
+	for _, rw := range allWorkflows(repos) {
+		if score(rw.Workflow) > 0 {
+			out = append(out, rw)
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := score(out[i].Workflow), score(out[j].Workflow)
+		if a != b {
+			return a > b
+		}
+		if out[i].Repo != out[j].Repo {
+			return out[i].Repo < out[j].Repo
+		}
+		return out[i].Workflow.Name < out[j].Workflow.Name
+	})
+
+	if len(out) > n {
+		out = out[:n]
+	}
+
+	return out
+}
+
+func topCostly(repos []*repositoryData, n int) []repoWorkflow {
+	return topN(repos, n, func(w *workflow) int64 { return int64(w.BillableMs) })
+}
+
+func topSlowest(repos []*repositoryData, n int) []repoWorkflow {
+	return topN(repos, n, func(w *workflow) int64 { return int64(w.AverageElapsed()) })
+}
+
+// dashboardSummary aggregates every workflow across repositories.
+type dashboardSummary struct {
+	Workflows  int
+	Runs       int
+	Successes  int
+	Total      int // runs that succeeded or failed, for the success rate
+	BillableMs int
+	Slowest    *repoWorkflow // nil if no workflow has finished runs
+}
+
+func summarize(repos []*repositoryData) dashboardSummary {
+	s := dashboardSummary{}
+
+	for _, rw := range allWorkflows(repos) {
+		successes, total, _ := rw.Workflow.SuccessRate()
+		s.Workflows++
+		s.Runs += len(rw.Workflow.Runs)
+		s.Successes += successes
+		s.Total += total
+		s.BillableMs += rw.Workflow.BillableMs
+	}
+
+	if slowest := topSlowest(repos, 1); len(slowest) > 0 {
+		s.Slowest = &slowest[0]
+	}
+
+	return s
+}
+
+// SuccessRate is the percentage of Total that succeeded, or 0 without runs.
+func (s dashboardSummary) SuccessRate() float64 {
+	if s.Total == 0 {
+		return 0
+	}
+	return float64(s.Successes) / float64(s.Total) * 100
+}
+
+// failureRate is the share of the runs the health strip covers that failed,
+// from 0 to 1. Workflows without runs have a rate of 0.
+func failureRate(w *workflow) float64 {
+	n := len(w.Runs)
+	if n > w.maxRuns() {
+		n = w.maxRuns()
+	}
+	if n == 0 {
+		return 0
+	}
+	return float64(w.FailureCount()) / float64(n)
+}
+
+// lastFailure is when the most recent failed run finished, or the zero time
+// if none of the runs the health strip covers failed.
+func lastFailure(w *workflow) time.Time {
+	for i, r := range w.Runs {
+		if i >= w.maxRuns() {
+			break
+		}
+		if r.failed() {
+			return r.Finished
+		}
+	}
+	return time.Time{}
+}
+
+// leastHealthyN picks the n least healthy enabled workflows: highest failure
+// rate first, then the most recent failure, then the most recent run, with
+// any remaining ties broken by repository and workflow name. Healthy
+// workflows fill whatever places are left.
+func leastHealthyN(repos []*repositoryData, n int) []repoWorkflow {
+	out := []repoWorkflow{}
+	for _, rw := range allWorkflows(repos) {
+		if !rw.Workflow.Disabled {
+			out = append(out, rw)
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i].Workflow, out[j].Workflow
+		if ra, rb := failureRate(a), failureRate(b); ra != rb {
+			return ra > rb
+		}
+		if fa, fb := lastFailure(a), lastFailure(b); !fa.Equal(fb) {
+			return fa.After(fb)
+		}
+		if la, lb := a.LastRun(), b.LastRun(); !la.Equal(lb) {
+			return la.After(lb)
+		}
+		if out[i].Repo != out[j].Repo {
+			return out[i].Repo < out[j].Repo
+		}
+		return a.Name < b.Name
+	})
+
+	if len(out) > n {
+		out = out[:n]
+	}
+
+	return out
+}
+
+// leastHealthy keeps the n least healthy workflows, as leastHealthyN ranks
+// them, across every repository or, with perRepo, within each one. n of 0
+// keeps everything.
+func leastHealthy(repos []*repositoryData, n int, perRepo bool) []*repositoryData {
+	if n <= 0 {
+		return repos
+	}
+
+	keep := map[*workflow]bool{}
+	if perRepo {
+		for _, r := range repos {
+			for _, rw := range leastHealthyN([]*repositoryData{r}, n) {
+				keep[rw.Workflow] = true
+			}
+		}
+	} else {
+		for _, rw := range leastHealthyN(repos, n) {
+			keep[rw.Workflow] = true
+		}
+	}
+
+	return filterWorkflows(repos, func(w *workflow) bool { return keep[w] })
+}
+
+// withMinRuns drops workflows with fewer than n runs in the window. n of 0
+// keeps everything.
+func withMinRuns(repos []*repositoryData, n int) []*repositoryData {
+	if n <= 0 {
+		return repos
+	}
+
+	return filterWorkflows(repos, func(w *workflow) bool { return len(w.Runs) >= n })
+}
+
+// filterWorkflows keeps the workflows keep returns true for. Repositories
+// are copied rather than trimmed in place, and dropped once they have
+// nothing left.
+func filterWorkflows(repos []*repositoryData, keep func(*workflow) bool) []*repositoryData {
+	out := []*repositoryData{}
+	for _, r := range repos {
+		workflows := []*workflow{}
+		for _, w := range r.Workflows {
+			if keep(w) {
+				workflows = append(workflows, w)
+			}
+		}
+		if len(workflows) == 0 {
+			continue
+		}
+		trimmed := *r
+		trimmed.Workflows = workflows
+		out = append(out, &trimmed)
+	}
+
+	return out
+}
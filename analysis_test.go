@@ -0,0 +1,184 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// workflowNames lists every workflow as repo:name, in order.
+func workflowNames(repos []*repositoryData) string {
+	names := []string{}
+	for _, rw := range allWorkflows(repos) {
+		names = append(names, rw.Repo+":"+rw.Workflow.Name)
+	}
+	return strings.Join(names, " ")
+}
+
+func TestWithMinRuns(t *testing.T) {
+	repos := []*repositoryData{
+		{Name: "cli/a", Workflows: []*workflow{
+			{Name: "Two", Runs: runsTaking(time.Minute, time.Minute)},
+			{Name: "Three", Runs: runsTaking(time.Minute, time.Minute, time.Minute)},
+		}},
+		{Name: "cli/b", Workflows: []*workflow{
+			{Name: "None"},
+		}},
+	}
+
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{n: 0, want: "cli/a:Two cli/a:Three cli/b:None"},
+		{n: 2, want: "cli/a:Two cli/a:Three"},
+		{n: 3, want: "cli/a:Three"},
+		{n: 4, want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := workflowNames(withMinRuns(repos, tt.n)); got != tt.want {
+			t.Errorf("--min-runs %d: got %q, want %q", tt.n, got, tt.want)
+		}
+	}
+
+	if len(repos[0].Workflows) != 2 || len(repos) != 2 {
+		t.Error("filtering trimmed the repositories it was given")
+	}
+}
+
+// healthDashboard has workflows of every kind of health, with ties on
+// failure rate and on recency.
+func healthDashboard() []*repositoryData {
+	at := func(conclusion, finished string) run {
+		return finishedRun("cli/a", conclusion, finished, time.Minute, 0)
+	}
+
+	return []*repositoryData{
+		{Name: "cli/a", Workflows: []*workflow{
+			{Name: "Flaky", Runs: []run{at("failure", "2024-05-06T12:00:00Z"), at("success", "2024-05-05T12:00:00Z")}},
+			{Name: "Idle"},
+			{Name: "Lint", Runs: []run{at("success", "2024-05-05T12:00:00Z")}},
+			{Name: "Broken", Runs: []run{at("failure", "2024-05-02T12:00:00Z"), at("failure", "2024-05-01T12:00:00Z")}},
+		}},
+		{Name: "cli/b", Workflows: []*workflow{
+			{Name: "Docs", Runs: []run{at("success", "2024-05-07T12:00:00Z"), at("success", "2024-05-06T12:00:00Z")}},
+			{Name: "Deploy", Runs: []run{at("success", "2024-05-05T12:00:00Z"), at("failure", "2024-05-04T12:00:00Z")}},
+			{Name: "Idle"},
+		}},
+		{Name: "cli/c", Workflows: []*workflow{
+			{Name: "Retired", Disabled: true},
+		}},
+	}
+}
+
+func TestLeastHealthyN(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int
+		want string
+	}{
+		{name: "least healthy", n: 1, want: "cli/a:Broken"},
+		// Flaky and Deploy both failed half their runs; Flaky failed last.
+		{name: "ties by most recent failure", n: 3, want: "cli/a:Broken cli/a:Flaky cli/b:Deploy"},
+		// Docs and Lint never failed; Docs ran last.
+		{name: "healthy by most recent run", n: 5, want: "cli/a:Broken cli/a:Flaky cli/b:Deploy cli/b:Docs cli/a:Lint"},
+		{
+			name: "more than there are",
+			n:    20,
+			want: "cli/a:Broken cli/a:Flaky cli/b:Deploy cli/b:Docs cli/a:Lint cli/a:Idle cli/b:Idle",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names := []string{}
+			for _, rw := range leastHealthyN(healthDashboard(), tt.n) {
+				names = append(names, rw.Repo+":"+rw.Workflow.Name)
+			}
+			if got := strings.Join(names, " "); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLeastHealthy(t *testing.T) {
+	tests := []struct {
+		name    string
+		n       int
+		perRepo bool
+		want    string
+	}{
+		{name: "everything", n: 0, want: "cli/a:Flaky cli/a:Idle cli/a:Lint cli/a:Broken cli/b:Docs cli/b:Deploy cli/b:Idle cli/c:Retired"},
+		// Repositories and workflows keep their order.
+		{name: "across repositories", n: 3, want: "cli/a:Flaky cli/a:Broken cli/b:Deploy"},
+		{name: "more than there are", n: 20, want: "cli/a:Flaky cli/a:Idle cli/a:Lint cli/a:Broken cli/b:Docs cli/b:Deploy cli/b:Idle"},
+		{name: "per repository", n: 1, perRepo: true, want: "cli/a:Broken cli/b:Deploy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := workflowNames(leastHealthy(healthDashboard(), tt.n, tt.perRepo)); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFailureRate(t *testing.T) {
+	runs := func(conclusions ...string) []run {
+		out := []run{}
+		for _, c := range conclusions {
+			out = append(out, run{Status: "completed", Conclusion: c})
+		}
+		return out
+	}
+
+	tests := []struct {
+		name string
+		w    *workflow
+		want float64
+	}{
+		{name: "no runs", w: &workflow{}, want: 0},
+		{name: "half failed", w: &workflow{Runs: runs("failure", "success")}, want: 0.5},
+		{name: "cancelled isn't a failure", w: &workflow{Runs: runs("failure", "cancelled", "skipped", "success")}, want: 0.25},
+		{name: "only covers max runs", w: &workflow{MaxRuns: 2, Runs: runs("failure", "success", "failure", "failure")}, want: 0.5},
+	}
+
+	for _, tt := range tests {
+		if got := failureRate(tt.w); got != tt.want {
+			t.Errorf("%s: got %g, want %g", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPivotByWorkflow(t *testing.T) {
+	repos := []*repositoryData{
+		{Name: "cli/a", Workflows: []*workflow{{Name: "lint"}, {Name: "Deploy"}, {Name: "CI"}}},
+		{Name: "cli/b", Workflows: []*workflow{{Name: "CI"}, {Name: "Deploy"}}},
+		{Name: "cli/c", Workflows: []*workflow{{Name: "ci"}}},
+		{Name: "cli/empty"},
+	}
+
+	got := []string{}
+	for _, g := range pivotByWorkflow(repos) {
+		names := []string{}
+		for _, rw := range g.Workflows {
+			if rw.Workflow.Name != g.Name {
+				t.Errorf("%s grouped under %s", rw.Workflow.Name, g.Name)
+			}
+			names = append(names, rw.Repo)
+		}
+		got = append(got, g.Name+"="+strings.Join(names, " "))
+	}
+	// Names differing only in case are grouped apart but sorted together.
+	want := []string{"CI=cli/a cli/b", "ci=cli/c", "Deploy=cli/a cli/b", "lint=cli/a"}
+	if strings.Join(got, "; ") != strings.Join(want, "; ") {
+		t.Errorf("got %s\nwant %s", strings.Join(got, "; "), strings.Join(want, "; "))
+	}
+
+	if groups := pivotByWorkflow(nil); len(groups) != 0 {
+		t.Errorf("got %d groups without repositories", len(groups))
+	}
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	gh "github.com/cli/go-gh"
+	"github.com/cli/go-gh/pkg/api"
+	"github.com/cli/go-gh/pkg/auth"
+)
+
+const defaultRetries = 3
+
+// apiRetries is how many times a failed call is retried, from --retries,
+// when the failure is worth retrying.
+var apiRetries = defaultRetries
+
+// retryDelay is how long to wait before the first retry; each retry after
+// that waits twice as long as the one before.
+var retryDelay = time.Second
+
+// restClient makes every API call; newGHFetcher points it at --host.
+var restClient api.RESTClient
+
+// errNotAuthenticated is returned when there is no token for the host, or
+// the API rejects the one there is.
+var errNotAuthenticated = errors.New("not authenticated; run `gh auth login` or set GH_TOKEN")
+
+// newRESTClient builds a client for host (gh's default host when empty)
+// that caches responses for apiCacheTime, like `gh api --cache`. The token
+// comes from GH_TOKEN, GITHUB_TOKEN (or their GH_ENTERPRISE_ variants) or gh's
+// own login, and its absence is reported up front.
+func newRESTClient(host string, transport http.RoundTripper) (api.RESTClient, error) {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	tokenHost := host
+	if tokenHost == "" {
+		tokenHost, _ = auth.DefaultHost()
+	}
+	if token, _ := auth.TokenForHost(tokenHost); token == "" {
+		return nil, errNotAuthenticated
+	}
+
+	return gh.RESTClient(&api.ClientOptions{
+		Host:        host,
+		EnableCache: true,
+		CacheTTL:    apiCacheTime,
+		Transport:   uncachedRateLimits{transport},
+	})
+}
+
+// uncachedRateLimits fails 429 responses before go-gh's cache sees them. The
+// cache keeps every response below 500 apart from 403s, so retries would
+// otherwise be answered with the same 429 until it expired.
+type uncachedRateLimits struct {
+	rt http.RoundTripper
+}
+
+func (u uncachedRateLimits) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := u.rt.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusTooManyRequests {
+		return resp, err
+	}
+	defer resp.Body.Close()
+
+	return nil, api.HandleHTTPError(resp)
+}
+
+// errAPIBudget is returned for calls past --max-api-calls.
+var errAPIBudget = errors.New("API call budget exhausted")
+
+// callBudget caps the number of API requests, counting retries. A zero max
+// means no cap.
+type callBudget struct {
+	mu      sync.Mutex
+	max     int
+	used    int
+	refused bool
+}
+
+// apiBudget counts calls against --max-api-calls, starting over with each
+// fetch of the dashboard.
+var apiBudget = &callBudget{}
+
+// spend takes a call from the budget, reporting false once none are left.
+func (b *callBudget) spend() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.max > 0 && b.used >= b.max {
+		b.refused = true
+		return false
+	}
+	b.used++
+	return true
+}
+
+// exhausted reports whether a call has been refused since the last reset.
+func (b *callBudget) exhausted() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.refused
+}
+
+func (b *callBudget) reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.used, b.refused = 0, false
+}
+
+// apiGet fetches a REST API path, or a full API URL, into response. Calls run
+// under apiLimiter and are retried with a growing delay when rate limited or
+// when the failure looks transient.
+func apiGet(ctx context.Context, path string, response interface{}) error {
+	_, err := apiGetPage(ctx, path, response)
+	return err
+}
+
+// apiGetPage is apiGet for paginated endpoints. It also returns the URL of
+// the next page, or "" on the last page. Once ctx is done it returns ctx's
+// error rather than retrying.
+func apiGetPage(ctx context.Context, path string, response interface{}) (next string, err error) {
+	for attempt := 0; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		if !apiBudget.spend() {
+			return "", errAPIBudget
+		}
+
+		var pressure bool
+		apiLimiter.acquire()
+		pressure, next, err = doGet(ctx, path, response)
+		apiLimiter.release(pressure)
+
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return next, ctxErr
+		}
+
+		if isUnauthorized(err) {
+			return next, errNotAuthenticated
+		}
+
+		rateLimited := isRateLimited(err)
+		if !rateLimited && !isTransient(err) {
+			return next, err
+		}
+		if attempt == apiRetries {
+			if rateLimited {
+				return next, newRateLimitError(err, time.Now())
+			}
+			return next, err
+		}
+
+		delay := retryDelay << uint(attempt)
+		logger.debugf("%s failed (%s), retrying in %s", path, err, delay)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return next, ctx.Err()
+		}
+	}
+}
+
+// doGet performs a single request, reporting whether the response signalled
+// rate limit pressure and the next page's URL if any.
+func doGet(ctx context.Context, path string, response interface{}) (bool, string, error) {
+	start := time.Now()
+	resp, err := restClient.RequestWithContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		logger.debugf("GET %s failed after %s: %s", path, time.Since(start).Round(time.Millisecond), err)
+		return isRateLimited(err), "", err
+	}
+	logger.debugf("GET %s %d in %s", path, resp.StatusCode, time.Since(start).Round(time.Millisecond))
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return false, "", fmt.Errorf("could not parse json: %w", err)
+	}
+
+	return lowOnRateLimit(resp.Header), nextPage(resp.Header), nil
+}
+
+var linkNextRE = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// nextPage extracts the rel="next" URL from a Link header.
+func nextPage(h http.Header) string {
+	if m := linkNextRE.FindStringSubmatch(h.Get("Link")); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// lowOnRateLimit reports whether fewer than a tenth of the rate limit's
+// requests remain.
+func lowOnRateLimit(h http.Header) bool {
+	remaining, err := strconv.Atoi(h.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return false
+	}
+	limit, err := strconv.Atoi(h.Get("X-RateLimit-Limit"))
+	if err != nil || limit == 0 {
+		return false
+	}
+
+	return remaining*10 < limit
+}
+
+// isUnauthorized reports whether a request failed with a 401, meaning the
+// token is missing, expired or revoked.
+func isUnauthorized(err error) bool {
+	var httpErr api.HTTPError
+	return errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusUnauthorized
+}
+
+// isNotFound reports whether a request failed with a 404.
+func isNotFound(err error) bool {
+	var httpErr api.HTTPError
+	return errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound
+}
+
+// isTransient reports whether a request failed in a way that may well succeed
+// if tried again: a 5xx response or a network error. Other 4xx responses
+// won't change on retry.
+func isTransient(err error) bool {
+	var httpErr api.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode >= 500
+	}
+
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// isRateLimited reports whether a request failed because of the primary or
+// secondary rate limit.
+func isRateLimited(err error) bool {
+	var httpErr api.HTTPError
+	if !errors.As(err, &httpErr) {
+		return false
+	}
+
+	switch httpErr.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusForbidden:
+		return httpErr.Headers.Get("X-RateLimit-Remaining") == "0" ||
+			strings.Contains(strings.ToLower(httpErr.Message), "rate limit")
+	}
+
+	return false
+}
+
+// rateLimitError replaces the API's own error once retries are exhausted, so
+// users are told what happened and when they can try again.
+type rateLimitError struct {
+	Reset time.Time // zero when the response didn't say
+	err   error
+}
+
+func (e *rateLimitError) Error() string {
+	if e.Reset.IsZero() {
+		return "GitHub API rate limit exceeded; try again later"
+	}
+	return fmt.Sprintf("GitHub API rate limit exceeded; resets at %s", e.Reset.Local().Format(time.Kitchen))
+}
+
+func (e *rateLimitError) Unwrap() error {
+	return e.err
+}
+
+// newRateLimitError reads when the limit resets from X-RateLimit-Reset, or
+// from Retry-After for secondary limits.
+func newRateLimitError(err error, now time.Time) error {
+	rlErr := &rateLimitError{err: err}
+
+	var httpErr api.HTTPError
+	if !errors.As(err, &httpErr) {
+		return rlErr
+	}
+
+	if reset, err := strconv.ParseInt(httpErr.Headers.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		rlErr.Reset = time.Unix(reset, 0)
+	} else if after, err := strconv.Atoi(httpErr.Headers.Get("Retry-After")); err == nil {
+		rlErr.Reset = now.Add(time.Duration(after) * time.Second)
+	}
+
+	return rlErr
+}
@@ -0,0 +1,513 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cli/go-gh/pkg/api"
+)
+
+// fakeResponse is a canned API response.
+type fakeResponse struct {
+	status int
+	body   string
+	header map[string]string
+}
+
+// fakeTransport answers each request for a path with the next of its
+// responses, repeating the last one once they run out.
+type fakeTransport struct {
+	mu        sync.Mutex
+	responses map[string][]fakeResponse
+	requests  []string
+}
+
+func (ft *fakeTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	if r.URL.RawQuery != "" {
+		key += "?" + r.URL.RawQuery
+	}
+	ft.requests = append(ft.requests, key)
+
+	queue, ok := ft.responses[key]
+	if !ok {
+		queue = []fakeResponse{{status: http.StatusNotFound, body: `{"message": "Not Found"}`}}
+	}
+	resp := queue[0]
+	if len(queue) > 1 {
+		ft.responses[key] = queue[1:]
+	}
+
+	header := http.Header{"Content-Type": []string{"application/json; charset=utf-8"}}
+	for k, v := range resp.header {
+		header.Set(k, v)
+	}
+	return &http.Response{
+		StatusCode: resp.status,
+		Status:     fmt.Sprintf("%d %s", resp.status, http.StatusText(resp.status)),
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(resp.body)),
+		Request:    r,
+	}, nil
+}
+
+// count reports how many requests were made for key.
+func (ft *fakeTransport) count(key string) int {
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+
+	n := 0
+	for _, r := range ft.requests {
+		if r == key {
+			n++
+		}
+	}
+	return n
+}
+
+// useFakeAPI points restClient at ft, with quick retries and no caching
+// beyond the test.
+func useFakeAPI(t *testing.T, ft *fakeTransport, retries int) {
+	t.Helper()
+	t.Setenv("GH_TOKEN", "test")
+	t.Setenv("TMPDIR", t.TempDir())
+
+	client, err := newRESTClient("github.com", ft)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldClient, oldRetries, oldDelay, oldLimiter := restClient, apiRetries, retryDelay, apiLimiter
+	restClient, apiRetries, retryDelay = client, retries, time.Millisecond
+	apiLimiter = newAdaptiveLimiter(1, 4, 8)
+	apiBudget.reset()
+	t.Cleanup(func() {
+		restClient, apiRetries, retryDelay, apiLimiter = oldClient, oldRetries, oldDelay, oldLimiter
+		apiBudget.max = 0
+		apiBudget.reset()
+	})
+}
+
+func ok(body string) fakeResponse {
+	return fakeResponse{status: http.StatusOK, body: body}
+}
+
+func TestAPIGetRetries(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	tests := []struct {
+		name      string
+		responses []fakeResponse
+		wantCalls int
+		wantErr   func(err error) bool
+	}{
+		{
+			name:      "success",
+			responses: []fakeResponse{ok(`{"full_name": "cli/cli"}`)},
+			wantCalls: 1,
+		},
+		{
+			name: "5xx then success",
+			responses: []fakeResponse{
+				{status: http.StatusBadGateway, body: `{"message": "Bad Gateway"}`},
+				{status: http.StatusServiceUnavailable, body: `{"message": "Unavailable"}`},
+				ok(`{"full_name": "cli/cli"}`),
+			},
+			wantCalls: 3,
+		},
+		{
+			name:      "5xx until out of retries",
+			responses: []fakeResponse{{status: http.StatusInternalServerError, body: `{"message": "Server Error"}`}},
+			wantCalls: 3,
+			wantErr: func(err error) bool {
+				var httpErr api.HTTPError
+				return errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusInternalServerError
+			},
+		},
+		{
+			name: "429 then success",
+			responses: []fakeResponse{
+				{status: http.StatusTooManyRequests, body: `{"message": "Too Many Requests"}`},
+				ok(`{"full_name": "cli/cli"}`),
+			},
+			wantCalls: 2,
+		},
+		{
+			name:      "429 until out of retries",
+			responses: []fakeResponse{{status: http.StatusTooManyRequests, body: `{"message": "Too Many Requests"}`, header: map[string]string{"Retry-After": "30"}}},
+			wantCalls: 3,
+			wantErr: func(err error) bool {
+				var rlErr *rateLimitError
+				return errors.As(err, &rlErr) && time.Until(rlErr.Reset) > 20*time.Second
+			},
+		},
+		{
+			name: "secondary rate limit then success",
+			responses: []fakeResponse{
+				{status: http.StatusForbidden, body: `{"message": "You have exceeded a secondary rate limit."}`, header: map[string]string{"Retry-After": "60"}},
+				ok(`{"full_name": "cli/cli"}`),
+			},
+			wantCalls: 2,
+		},
+		{
+			name:      "secondary rate limit until out of retries",
+			responses: []fakeResponse{{status: http.StatusForbidden, body: `{"message": "You have exceeded a secondary rate limit."}`, header: map[string]string{"Retry-After": "60"}}},
+			wantCalls: 3,
+			wantErr: func(err error) bool {
+				var rlErr *rateLimitError
+				return errors.As(err, &rlErr) && time.Until(rlErr.Reset) > 50*time.Second
+			},
+		},
+		{
+			name: "primary rate limit until out of retries",
+			responses: []fakeResponse{{status: http.StatusForbidden, body: `{"message": "API rate limit exceeded"}`, header: map[string]string{
+				"X-RateLimit-Remaining": "0",
+				"X-RateLimit-Reset":     strconv.FormatInt(reset.Unix(), 10),
+			}}},
+			wantCalls: 3,
+			wantErr: func(err error) bool {
+				var rlErr *rateLimitError
+				return errors.As(err, &rlErr) && rlErr.Reset.Equal(reset)
+			},
+		},
+		{
+			name:      "forbidden without a rate limit",
+			responses: []fakeResponse{{status: http.StatusForbidden, body: `{"message": "Resource not accessible by integration"}`}},
+			wantCalls: 1,
+			wantErr: func(err error) bool {
+				var rlErr *rateLimitError
+				return !errors.As(err, &rlErr) && strings.Contains(err.Error(), "not accessible")
+			},
+		},
+		{
+			name:      "not found",
+			responses: []fakeResponse{{status: http.StatusNotFound, body: `{"message": "Not Found"}`}},
+			wantCalls: 1,
+			wantErr:   isNotFound,
+		},
+		{
+			name:      "unauthorized",
+			responses: []fakeResponse{{status: http.StatusUnauthorized, body: `{"message": "Bad credentials"}`}},
+			wantCalls: 1,
+			wantErr: func(err error) bool {
+				return errors.Is(err, errNotAuthenticated)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ft := &fakeTransport{responses: map[string][]fakeResponse{"repos/cli/cli": tt.responses}}
+			useFakeAPI(t, ft, 2)
+
+			var repo repositoryData
+			err := apiGet(context.Background(), "repos/cli/cli", &repo)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %s after %v", err, ft.requests)
+				}
+				if repo.Name != "cli/cli" {
+					t.Errorf("got %+v", repo)
+				}
+			} else if err == nil || !tt.wantErr(err) {
+				t.Errorf("got error %v", err)
+			}
+			if got := ft.count("repos/cli/cli"); got != tt.wantCalls {
+				t.Errorf("got %d calls, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestAPIGetBudget(t *testing.T) {
+	ft := &fakeTransport{responses: map[string][]fakeResponse{
+		"repos/cli/cli": {{status: http.StatusBadGateway, body: `{"message": "Bad Gateway"}`}},
+	}}
+	useFakeAPI(t, ft, 5)
+	apiBudget.max = 2
+
+	var repo repositoryData
+	err := apiGet(context.Background(), "repos/cli/cli", &repo)
+	if !errors.Is(err, errAPIBudget) {
+		t.Errorf("got %v, want errAPIBudget", err)
+	}
+	if got := ft.count("repos/cli/cli"); got != 2 {
+		t.Errorf("got %d calls, want retries to count against the budget of 2", got)
+	}
+	if !apiBudget.exhausted() {
+		t.Error("budget not marked exhausted")
+	}
+}
+
+func TestAPIGetCancelledWhileWaitingToRetry(t *testing.T) {
+	ft := &fakeTransport{responses: map[string][]fakeResponse{
+		"repos/cli/cli": {{status: http.StatusBadGateway, body: `{"message": "Bad Gateway"}`}},
+	}}
+	useFakeAPI(t, ft, 5)
+	retryDelay = time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	var repo repositoryData
+	err := apiGet(ctx, "repos/cli/cli", &repo)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want the context's error", err)
+	}
+	if got := ft.count("repos/cli/cli"); got != 1 {
+		t.Errorf("got %d calls, want 1", got)
+	}
+}
+
+func TestGHFetcherRepos(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses map[string][]fakeResponse
+		want      string
+		wantErr   string
+		wantUsers int
+	}{
+		{
+			name: "organization",
+			responses: map[string][]fakeResponse{
+				"orgs/cli/repos?per_page=100": {ok(`[{"full_name": "cli/cli"}, {"full_name": "cli/go-gh"}]`)},
+			},
+			want: "cli/cli cli/go-gh",
+		},
+		{
+			name: "user",
+			responses: map[string][]fakeResponse{
+				"users/cli/repos?per_page=100": {ok(`[{"full_name": "cli/dotfiles"}]`)},
+			},
+			want:      "cli/dotfiles",
+			wantUsers: 1,
+		},
+		{
+			name:      "neither",
+			responses: map[string][]fakeResponse{},
+			wantErr:   "could not find a user or org called 'cli'",
+			wantUsers: 1,
+		},
+		{
+			name: "organization fails",
+			responses: map[string][]fakeResponse{
+				"orgs/cli/repos?per_page=100": {{status: http.StatusForbidden, body: `{"message": "Resource not accessible by integration"}`}},
+			},
+			wantErr: "Resource not accessible by integration",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ft := &fakeTransport{responses: tt.responses}
+			useFakeAPI(t, ft, 0)
+
+			repos, err := ghFetcher{}.Repos(context.Background(), "cli", 0)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("got error %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			} else if got := strings.Join(repoNames(repos), " "); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+			if got := ft.count("users/cli/repos?per_page=100"); got != tt.wantUsers {
+				t.Errorf("tried the user %d times, want %d", got, tt.wantUsers)
+			}
+		})
+	}
+}
+
+func TestGHFetcherReposPaginates(t *testing.T) {
+	ft := &fakeTransport{responses: map[string][]fakeResponse{
+		"orgs/cli/repos?per_page=100": {{
+			status: http.StatusOK,
+			body:   `[{"full_name": "cli/a"}, {"full_name": "cli/b"}]`,
+			header: map[string]string{"Link": `<https://api.github.com/orgs/cli/repos?per_page=100&page=2>; rel="next"`},
+		}},
+		"orgs/cli/repos?per_page=100&page=2": {ok(`[{"full_name": "cli/c"}]`)},
+	}}
+	useFakeAPI(t, ft, 0)
+
+	repos, err := ghFetcher{}.Repos(context.Background(), "cli", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(repoNames(repos), " "); got != "cli/a cli/b cli/c" {
+		t.Errorf("got %s", got)
+	}
+
+	repos, err = ghFetcher{}.Repos(context.Background(), "cli", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(repoNames(repos), " "); got != "cli/a cli/b" {
+		t.Errorf("got %s with a limit of 2", got)
+	}
+}
+
+func TestGHFetcherWorkflowsAndRuns(t *testing.T) {
+	ft := &fakeTransport{responses: map[string][]fakeResponse{
+		"repos/cli/cli/actions/workflows": {ok(`{"workflows": [{"id": 1, "name": "CI", "state": "active", "path": ".github/workflows/ci.yml", "url": "https://api.github.com/repos/cli/cli/actions/workflows/1"}]}`)},
+		"repos/cli/cli/actions/workflows/1/runs?per_page=2&branch=trunk": {{
+			status: http.StatusOK,
+			body:   `{"workflow_runs": [{"id": 11, "run_number": 3, "status": "completed", "conclusion": "success"}, {"id": 10, "run_number": 2}]}`,
+			header: map[string]string{"Link": `<https://api.github.com/repos/cli/cli/actions/workflows/1/runs?per_page=2&branch=trunk&page=2>; rel="next"`},
+		}},
+		"repos/cli/cli/actions/workflows/1/runs?per_page=2&branch=trunk&page=2": {ok(`{"workflow_runs": [{"id": 9, "run_number": 1}]}`)},
+	}}
+	useFakeAPI(t, ft, 0)
+
+	workflows, err := ghFetcher{}.Workflows(context.Background(), "cli/cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(workflows) != 1 || workflows[0].Name != "CI" || workflows[0].Path != ".github/workflows/ci.yml" {
+		t.Fatalf("got %+v", workflows)
+	}
+
+	runs, err := ghFetcher{}.Runs(context.Background(), workflows[0], "branch=trunk", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 2 || runs[0].RunNumber != 3 || runs[0].Conclusion != "success" {
+		t.Errorf("got %+v", runs)
+	}
+	if ft.count("repos/cli/cli/actions/workflows/1/runs?per_page=2&branch=trunk&page=2") != 0 {
+		t.Error("fetched another page after enough runs")
+	}
+}
+
+func TestRateLimitErrorMessage(t *testing.T) {
+	old := time.Local
+	time.Local = time.UTC
+	defer func() { time.Local = old }()
+
+	reset := time.Date(2024, 5, 6, 15, 4, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		response fakeResponse
+		want     string
+	}{
+		{
+			name: "primary limit",
+			response: fakeResponse{status: http.StatusForbidden, body: `{"message": "API rate limit exceeded"}`, header: map[string]string{
+				"X-RateLimit-Remaining": "0",
+				"X-RateLimit-Reset":     strconv.FormatInt(reset.Unix(), 10),
+			}},
+			want: "GitHub API rate limit exceeded; resets at 3:04PM",
+		},
+		{
+			name: "429 with a reset",
+			response: fakeResponse{status: http.StatusTooManyRequests, body: `{"message": "Too Many Requests"}`, header: map[string]string{
+				"X-RateLimit-Reset": strconv.FormatInt(reset.Unix(), 10),
+			}},
+			want: "GitHub API rate limit exceeded; resets at 3:04PM",
+		},
+		{
+			name:     "429 without a reset",
+			response: fakeResponse{status: http.StatusTooManyRequests, body: `{"message": "Too Many Requests"}`},
+			want:     "GitHub API rate limit exceeded; try again later",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ft := &fakeTransport{responses: map[string][]fakeResponse{"repos/cli/cli": {tt.response}}}
+			useFakeAPI(t, ft, 0)
+
+			var repo repositoryData
+			err := apiGet(context.Background(), "repos/cli/cli", &repo)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("got error %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRateLimitErrorRetryAfter(t *testing.T) {
+	old := time.Local
+	time.Local = time.UTC
+	defer func() { time.Local = old }()
+
+	now := time.Date(2024, 5, 6, 15, 4, 0, 0, time.UTC)
+	httpErr := api.HTTPError{StatusCode: http.StatusTooManyRequests, Headers: http.Header{"Retry-After": []string{"120"}}}
+
+	err := newRateLimitError(httpErr, now)
+	if want := "GitHub API rate limit exceeded; resets at 3:06PM"; err.Error() != want {
+		t.Errorf("got %q, want %q", err.Error(), want)
+	}
+	var wrapped api.HTTPError
+	if !errors.As(err, &wrapped) || wrapped.StatusCode != http.StatusTooManyRequests {
+		t.Error("rate limit error doesn't wrap the response's error")
+	}
+
+	err = newRateLimitError(errors.New("rate limited"), now)
+	if want := "GitHub API rate limit exceeded; try again later"; err.Error() != want {
+		t.Errorf("got %q without a response, want %q", err.Error(), want)
+	}
+}
+
+func TestNewRESTClientWithoutToken(t *testing.T) {
+	t.Setenv("GH_CONFIG_DIR", t.TempDir())
+	for _, name := range []string{"GH_TOKEN", "GITHUB_TOKEN", "GH_ENTERPRISE_TOKEN", "GITHUB_ENTERPRISE_TOKEN", "GH_HOST"} {
+		t.Setenv(name, "")
+	}
+
+	for _, host := range []string{"", "github.com", "ghe.example.com"} {
+		if _, err := newRESTClient(host, &fakeTransport{}); !errors.Is(err, errNotAuthenticated) {
+			t.Errorf("host %q: got %v, want errNotAuthenticated", host, err)
+		}
+	}
+}
+
+func TestUnauthorizedStopsTheDashboard(t *testing.T) {
+	badCredentials := []fakeResponse{{status: http.StatusUnauthorized, body: `{"message": "Bad credentials"}`}}
+
+	tests := []struct {
+		name string
+		args []string
+		path string
+	}{
+		{name: "named repositories", args: []string{"-r", "a,b"}, path: "repos/cli/a"},
+		{name: "listed repositories", path: "orgs/cli/repos?per_page=100"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			ft := &fakeTransport{responses: map[string][]fakeResponse{tt.path: badCredentials}}
+			useFakeAPI(t, ft, 2)
+
+			var stdout, stderr bytes.Buffer
+			args := append([]string{"--no-cache"}, append(tt.args, "cli")...)
+			got := runCLI(args, &stdout, &stderr, func(string) (Fetcher, error) { return ghFetcher{}, nil })
+			if got != 1 {
+				t.Errorf("got exit code %d, want 1", got)
+			}
+			if !strings.Contains(stderr.String(), errNotAuthenticated.Error()) || strings.Contains(stderr.String(), "skipped") {
+				t.Errorf("got stderr %q, want the dashboard stopped with %q", stderr.String(), errNotAuthenticated)
+			}
+			if got := ft.count(tt.path); got != 1 {
+				t.Errorf("got %d calls, want 1 without retries: %v", got, ft.requests)
+			}
+			if strings.Contains(strings.Join(ft.requests, " "), "repos/cli/b") {
+				t.Errorf("carried on after a 401: %v", ft.requests)
+			}
+		})
+	}
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dashboardCacheVersion is part of every cache key; bump it whenever what the
+// saved fields mean changes, eg how elapsed time is measured, so that old
+// entries are ignored. Changes to the fields themselves are caught by
+// dashboardCacheShape.
+const dashboardCacheVersion = 6
+
+// dashboardCacheShape describes every field saved in the cache, so that
+// adding, removing or retyping one changes every cache key.
+var dashboardCacheShape = typeShape(reflect.TypeOf(dashboardCache{}), map[reflect.Type]bool{})
+
+// typeShape describes t's structure: struct fields with their names, types
+// and tags, recursively. Types already in seen are named only, which stops
+// recursion on self-referencing types.
+func typeShape(t reflect.Type, seen map[reflect.Type]bool) string {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		return t.Kind().String() + " " + typeShape(t.Elem(), seen)
+	case reflect.Map:
+		return "map " + typeShape(t.Key(), seen) + " " + typeShape(t.Elem(), seen)
+	case reflect.Struct:
+		if seen[t] || t == reflect.TypeOf(time.Time{}) {
+			return t.String()
+		}
+		seen[t] = true
+		fields := []string{}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			fields = append(fields, fmt.Sprintf("%s %s %q", f.Name, typeShape(f.Type, seen), f.Tag))
+		}
+		return t.String() + "{" + strings.Join(fields, "; ") + "}"
+	}
+	return t.String()
+}
+
+// dashboardCache is what gets written to disk: the assembled dashboard data,
+// when it was collected and the warnings collecting it raised, such as
+// skipped repositories.
+type dashboardCache struct {
+	SavedAt  time.Time
+	Repos    []*repositoryData
+	Warnings []string
+}
+
+// dashboardCacheKey identifies a dashboard by every option that changes what
+// gets fetched. Options that only affect rendering are left out so that, say,
+// switching --format reuses the same data.
+func dashboardCacheKey(opts *options) string {
+	key, _ := json.Marshal(struct {
+		Version      int
+		Shape        string
+		Host         string
+		Strict       bool
+		Selectors    []string
+		Repositories []string
+		Last         time.Duration
+		Since        time.Time
+		Branch       string
+		Default      bool
+		Actor        string
+		Event        string
+		Workflows    []string
+		WorkflowIDs  []int
+		Files        []string
+		MaxRuns      int
+		Artifacts    bool
+		Limit        int
+		Sort         string
+		NoBillable   bool
+		BillableOS   string
+		Disabled     bool
+		Archived     bool
+		Visibility   string
+		Exclude      []string
+		Running      bool
+	}{
+		dashboardCacheVersion,
+		dashboardCacheShape,
+		opts.Host,
+		opts.Strict,
+		opts.Selectors,
+		opts.Repositories,
+		opts.Last,
+		opts.Since,
+		opts.Branch,
+		opts.DefaultBranchOnly,
+		opts.Actor,
+		opts.Event,
+		opts.Workflows,
+		opts.WorkflowIDs,
+		opts.WorkflowFiles,
+		opts.MaxRuns,
+		opts.Artifacts,
+		opts.Limit,
+		opts.Sort,
+		opts.NoBillable,
+		opts.BillableOS,
+		opts.IncludeDisabled,
+		opts.IncludeArchived,
+		opts.Visibility,
+		opts.ExcludeRepos,
+		opts.IncludeRunning,
+	})
+
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:])
+}
+
+func dashboardCachePath(opts *options) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "actions-dashboard", dashboardCacheKey(opts)+".json"), nil
+}
+
+// readDashboardCache returns the cache at path if it was saved less than ttl
+// before now.
+func readDashboardCache(path string, ttl time.Duration, now time.Time) (dashboardCache, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return dashboardCache{}, false
+	}
+
+	var cache dashboardCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return dashboardCache{}, false
+	}
+
+	if now.Sub(cache.SavedAt) >= ttl {
+		return dashboardCache{}, false
+	}
+
+	return cache, true
+}
+
+// dataFreshness is when the dashboard's data was fetched from the API, and
+// whether it was then read back from the on-disk cache.
+type dataFreshness struct {
+	FetchedAt time.Time
+	Cached    bool
+}
+
+func (f dataFreshness) String() string {
+	s := "Data as of " + f.FetchedAt.Format("Jan 2 15:04")
+	if f.Cached {
+		s += " (cached; --no-cache to refresh)"
+	}
+	return s
+}
+
+func writeDashboardCache(path string, repos []*repositoryData, warned []string, now time.Time) error {
+	data, err := json.Marshal(dashboardCache{SavedAt: now, Repos: repos, Warnings: warned})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o600)
+}
+
+// cachedFetchDashboard serves the dashboard from the on-disk cache when a
+// fresh enough copy exists, and otherwise fetches it and saves it for next
+// time. Failing to read or write the cache never fails the dashboard. each is
+// called as for fetchDashboardEach.
+func cachedFetchDashboard(ctx context.Context, f Fetcher, opts *options, each func(*repositoryData)) ([]*repositoryData, dataFreshness, error) {
+	now := time.Now()
+	fresh := dataFreshness{FetchedAt: now}
+
+	if opts.NoCache {
+		repos, err := fetchDashboardEach(ctx, f, opts, each)
+		return repos, fresh, err
+	}
+
+	path, err := dashboardCachePath(opts)
+	if err != nil {
+		repos, err := fetchDashboardEach(ctx, f, opts, each)
+		return repos, fresh, err
+	}
+
+	if cache, ok := readDashboardCache(path, opts.CacheTTL, now); ok {
+		logger.debugf("dashboard cache hit: %s", path)
+		for _, msg := range cache.Warnings {
+			warnings.add("%s", msg)
+		}
+		for _, r := range cache.Repos {
+			each(r)
+		}
+		return cache.Repos, dataFreshness{FetchedAt: cache.SavedAt, Cached: true}, nil
+	}
+
+	logger.debugf("dashboard cache miss: %s", path)
+
+	warnedBefore := len(warnings.list())
+	repos, err := fetchDashboardEach(ctx, f, opts, each)
+	if err != nil {
+		return nil, fresh, err
+	}
+
+	if apiBudget.exhausted() || ctx.Err() != nil {
+		// An incomplete dashboard shouldn't be served as the whole thing.
+		return repos, fresh, nil
+	}
+
+	if err := writeDashboardCache(path, repos, warnings.list()[warnedBefore:], now); err != nil {
+		logger.debugf("could not write dashboard cache: %s", err)
+	}
+
+	return repos, fresh, nil
+}
+
+// workflowListTTL is how long watch and stream mode reuse a repository's
+// workflow list before listing it again. Workflows are rarely added, so this
+// can be much longer than the refresh interval.
+const workflowListTTL = 15 * time.Minute
+
+// workflowListCache keeps each repository's workflow list in memory so that
+// polling modes only fetch runs on most refreshes. A zero ttl disables it.
+type workflowListCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]workflowListEntry
+}
+
+type workflowListEntry struct {
+	fetchedAt time.Time
+	workflows []workflowsPayload
+}
+
+// workflowLists is only given a ttl for --watch and --stream, where the same
+// repositories are fetched over and over.
+var workflowLists = &workflowListCache{}
+
+func (c *workflowListCache) get(repo string, now time.Time) ([]workflowsPayload, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[repo]
+	if !ok || now.Sub(e.fetchedAt) >= c.ttl {
+		return nil, false
+	}
+	return e.workflows, true
+}
+
+func (c *workflowListCache) put(repo string, workflows []workflowsPayload, now time.Time) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries == nil {
+		c.entries = map[string]workflowListEntry{}
+	}
+	c.entries[repo] = workflowListEntry{fetchedAt: now, workflows: workflows}
+}
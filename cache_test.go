@@ -0,0 +1,293 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDashboardCacheRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "cache.json")
+	savedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	if err := writeDashboardCache(path, goldenDashboard(), []string{"skipped: repository cli/typo not found"}, savedAt); err != nil {
+		t.Fatal(err)
+	}
+
+	cache, ok := readDashboardCache(path, time.Hour, savedAt.Add(time.Minute))
+	if !ok {
+		t.Fatal("fresh cache missed")
+	}
+	if !cache.SavedAt.Equal(savedAt) {
+		t.Errorf("got saved at %s, want %s", cache.SavedAt, savedAt)
+	}
+	if got, want := strings.Join(repoNames(cache.Repos), " "), strings.Join(repoNames(goldenDashboard()), " "); got != want {
+		t.Errorf("got repos %s, want %s", got, want)
+	}
+	if got := cache.Repos[0].Workflows[0].Runs; len(got) != len(goldenDashboard()[0].Workflows[0].Runs) {
+		t.Errorf("got %d runs back", len(got))
+	}
+	if len(cache.Warnings) != 1 || cache.Warnings[0] != "skipped: repository cli/typo not found" {
+		t.Errorf("got warnings %q", cache.Warnings)
+	}
+}
+
+func TestReadDashboardCacheExpiry(t *testing.T) {
+	dir := t.TempDir()
+	savedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	path := filepath.Join(dir, "cache.json")
+	if err := writeDashboardCache(path, goldenDashboard(), nil, savedAt); err != nil {
+		t.Fatal(err)
+	}
+	corrupt := filepath.Join(dir, "corrupt.json")
+	if err := os.WriteFile(corrupt, []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		age  time.Duration
+		want bool
+	}{
+		{name: "just saved", path: path, want: true},
+		{name: "inside the ttl", path: path, age: 5*time.Minute - time.Second, want: true},
+		{name: "at the ttl", path: path, age: 5 * time.Minute},
+		{name: "past the ttl", path: path, age: time.Hour},
+		{name: "missing", path: filepath.Join(dir, "missing.json")},
+		{name: "corrupt", path: corrupt},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := readDashboardCache(tt.path, 5*time.Minute, savedAt.Add(tt.age)); ok != tt.want {
+				t.Errorf("got hit %t, want %t", ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestCachedFetchDashboard(t *testing.T) {
+	f := dashboardFixture()
+	opts := testOptions(t, "-r", "a,c,typo", "cli")
+
+	repos, asOf, err := cachedFetchDashboard(context.Background(), f, opts, func(*repositoryData) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if asOf.Cached {
+		t.Error("first fetch was served from the cache")
+	}
+	if got := strings.Join(repoNames(repos), " "); got != "cli/a cli/c" {
+		t.Fatalf("got repos %s", got)
+	}
+	saved := warnings.list()
+	if len(saved) != 1 {
+		t.Fatalf("got warnings %q", saved)
+	}
+	warnings.flush(io.Discard)
+	fetched := len(f.calls)
+
+	var handed []string
+	cached, asOf, err := cachedFetchDashboard(context.Background(), f, opts, func(r *repositoryData) {
+		handed = append(handed, r.Name)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !asOf.Cached {
+		t.Error("second fetch missed the cache")
+	}
+	if len(f.calls) != fetched {
+		t.Errorf("cache hit made %d API calls", len(f.calls)-fetched)
+	}
+	if got := strings.Join(handed, " "); got != "cli/a cli/c" {
+		t.Errorf("handed %s", got)
+	}
+	if len(cached[0].Workflows) != 2 || len(cached[0].Workflows[0].Runs) != 2 {
+		t.Errorf("got %d workflows back", len(cached[0].Workflows))
+	}
+	if got := warnings.list(); len(got) != 1 || got[0] != saved[0] {
+		t.Errorf("got warnings %q, want %q restored", got, saved)
+	}
+}
+
+func TestCachedFetchDashboardNoCache(t *testing.T) {
+	f := dashboardFixture()
+	opts := testOptions(t, "--no-cache", "cli")
+
+	for i := 0; i < 2; i++ {
+		if _, asOf, err := cachedFetchDashboard(context.Background(), f, opts, func(*repositoryData) {}); err != nil {
+			t.Fatal(err)
+		} else if asOf.Cached {
+			t.Fatal("served from the cache with --no-cache")
+		}
+	}
+	if got := f.called("repos:cli"); got != 2 {
+		t.Errorf("listed repositories %d times, want 2", got)
+	}
+}
+
+func TestCachedFetchDashboardSkipsCutShort(t *testing.T) {
+	f := dashboardFixture()
+	w := f.workflows["cli/c"][0]
+	f.slow["runs:"+w.URL] = true
+
+	opts := testOptions(t, "--timeout", "50ms", "cli")
+	ctx, cancel := opts.fetchContext(context.Background())
+	defer cancel()
+
+	if _, _, err := cachedFetchDashboard(ctx, f, opts, func(*repositoryData) {}); err != nil {
+		t.Fatal(err)
+	}
+
+	path, err := dashboardCachePath(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("saved a dashboard that timed out: %v", err)
+	}
+}
+
+func TestWorkflowListCache(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	ci := []workflowsPayload{{Id: 1, Name: "CI"}}
+
+	off := &workflowListCache{}
+	off.put("cli/a", ci, now)
+	if _, ok := off.get("cli/a", now); ok {
+		t.Error("hit with a zero ttl")
+	}
+
+	c := &workflowListCache{ttl: time.Minute}
+	if _, ok := c.get("cli/a", now); ok {
+		t.Error("hit before anything was put")
+	}
+	c.put("cli/a", ci, now)
+
+	tests := []struct {
+		name string
+		repo string
+		age  time.Duration
+		want bool
+	}{
+		{name: "just put", repo: "cli/a", want: true},
+		{name: "inside the ttl", repo: "cli/a", age: time.Minute - time.Second, want: true},
+		{name: "at the ttl", repo: "cli/a", age: time.Minute},
+		{name: "past the ttl", repo: "cli/a", age: time.Hour},
+		{name: "another repository", repo: "cli/b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := c.get(tt.repo, now.Add(tt.age))
+			if ok != tt.want {
+				t.Fatalf("got hit %t, want %t", ok, tt.want)
+			}
+			if ok && (len(got) != 1 || got[0].Name != "CI") {
+				t.Errorf("got %+v", got)
+			}
+		})
+	}
+}
+
+func TestListWorkflowsCache(t *testing.T) {
+	old := workflowLists
+	defer func() { workflowLists = old }()
+
+	f := newFakeFetcher()
+	f.addRepo("cli/a", false)
+	f.addWorkflow("cli/a", "CI")
+
+	tests := []struct {
+		name      string
+		ttl       time.Duration
+		wantCalls int
+	}{
+		{name: "off", wantCalls: 3},
+		{name: "on", ttl: time.Hour, wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workflowLists = &workflowListCache{ttl: tt.ttl}
+			before := f.called("workflows:cli/a")
+
+			for i := 0; i < 3; i++ {
+				workflows, err := listWorkflows(context.Background(), f, "cli/a")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(workflows) != 1 || workflows[0].Name != "CI" {
+					t.Fatalf("got %+v", workflows)
+				}
+			}
+			if got := f.called("workflows:cli/a") - before; got != tt.wantCalls {
+				t.Errorf("listed workflows %d times, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+
+	// Failures aren't kept, so the next poll tries again.
+	workflowLists = &workflowListCache{ttl: time.Hour}
+	f.errs["workflows:cli/b"] = errors.New("boom")
+	for i := 0; i < 2; i++ {
+		if _, err := listWorkflows(context.Background(), f, "cli/b"); err == nil {
+			t.Fatal("got no error")
+		}
+	}
+	if got := f.called("workflows:cli/b"); got != 2 {
+		t.Errorf("listed workflows %d times after failures, want 2", got)
+	}
+}
+
+func TestDataFreshnessString(t *testing.T) {
+	at := time.Date(2024, 3, 1, 9, 5, 0, 0, time.UTC)
+
+	if got, want := (dataFreshness{FetchedAt: at}).String(), "Data as of Mar 1 09:05"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := (dataFreshness{FetchedAt: at, Cached: true}).String(), "Data as of Mar 1 09:05 (cached; --no-cache to refresh)"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestDataFreshnessLine(t *testing.T) {
+	isolate(t)
+	withColor(t, false)
+	oldColors, oldGlyphs, oldLogger := colors, glyphs, logger
+	defer func() { colors, glyphs, logger = oldColors, oldGlyphs, oldLogger }()
+
+	f := dashboardFixture()
+	render := func(args ...string) string {
+		t.Helper()
+		var stdout, stderr bytes.Buffer
+		if code := runCLI(append(args, "cli"), &stdout, &stderr, func(string) (Fetcher, error) { return f, nil }); code != 0 {
+			t.Fatalf("got exit code %d; stderr: %s", code, stderr.String())
+		}
+		return stdout.String()
+	}
+
+	for _, format := range []string{"--table", "--markdown", "--format=cards"} {
+		t.Run(format, func(t *testing.T) {
+			fresh := render(format, "--no-cache")
+			if !strings.Contains(fresh, "Data as of ") || strings.Contains(fresh, "(cached") {
+				t.Errorf("fetched output doesn't say when it was fetched:\n%s", fresh)
+			}
+		})
+	}
+
+	// The second run is served from the cache the first one saved.
+	render("--table")
+	cached := render("--table")
+	if !strings.Contains(cached, "(cached; --no-cache to refresh)") {
+		t.Errorf("cached output doesn't say so:\n%s", cached)
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+	"github.com/vilmibm/actions-dashboard/util"
+)
+
+// cardRenderer prints the cards dashboard a piece at a time so that each
+// repository can be shown as soon as it has been fetched.
+type cardRenderer struct {
+	out           io.Writer
+	opts          *options
+	render        renderOptions
+	terminalWidth int
+	cardsPerRow   int
+	cardStyle     lipgloss.Style
+	repoNameStyle lipgloss.Style
+	repoHintStyle lipgloss.Style
+}
+
+func newCardRenderer(out io.Writer, opts *options) *cardRenderer {
+	columnWidth := defaultWorkflowNameLength + 5 // account for ellipsis and padding/border
+	terminalWidth := getTerminalWidth(opts.Width)
+	cardsPerRow := (terminalWidth / columnWidth) - 1
+	if cardsPerRow < 1 {
+		// Narrow terminals still get one card per row.
+		cardsPerRow = 1
+	}
+
+	return &cardRenderer{
+		out:           out,
+		opts:          opts,
+		render:        opts.render(),
+		terminalWidth: terminalWidth,
+		cardsPerRow:   cardsPerRow,
+		cardStyle: lipgloss.NewStyle().
+			Align(lipgloss.Left).
+			Padding(1).
+			Width(columnWidth).
+			BorderStyle(lipgloss.DoubleBorder()).
+			BorderForeground(colors.Border),
+		repoNameStyle: lipgloss.NewStyle().Bold(colorEnabled),
+		repoHintStyle: lipgloss.NewStyle().Foreground(colors.Label).Italic(colorEnabled),
+	}
+}
+
+func (c *cardRenderer) header() {
+	if c.opts.Quiet {
+		return
+	}
+
+	titleStyle := lipgloss.NewStyle().Bold(colorEnabled).Align(lipgloss.Center).Width(c.terminalWidth)
+
+	fmt.Fprintln(c.out, titleStyle.Render(fmt.Sprintf("GitHub Actions dashboard for %s %s", c.opts.owners(), c.opts.period())))
+	fmt.Fprintln(c.out, lipgloss.PlaceHorizontal(c.terminalWidth, lipgloss.Center, renderLegend()))
+}
+
+// footer prints totals across every repository in a box, which is why it
+// has to wait until everything is fetched, along with how fresh the data is.
+func (c *cardRenderer) footer(repos []*repositoryData, asOf dataFreshness) {
+	if c.opts.Quiet {
+		return
+	}
+
+	labelStyle := lipgloss.NewStyle().Foreground(colors.Label)
+	boxStyle := lipgloss.NewStyle().
+		Padding(0, 1).
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(colors.Border)
+
+	s := summarize(repos)
+	lines := []string{
+		fmt.Sprintf("%s %d", labelStyle.Render("Workflows:"), s.Workflows),
+		fmt.Sprintf("%s %d", labelStyle.Render("Runs analyzed:"), s.Runs),
+	}
+	if s.Total > 0 {
+		lines = append(lines, fmt.Sprintf("%s %d/%d (%.0f%%)", labelStyle.Render("Success:"), s.Successes, s.Total, s.SuccessRate()))
+	}
+	if s.Slowest != nil {
+		lines = append(lines, fmt.Sprintf("%s %s: %s (%s)", labelStyle.Render("Slowest:"),
+			s.Slowest.Repo, s.Slowest.Workflow.Name, s.Slowest.Workflow.AverageElapsed()))
+	}
+	lines = append(lines, fmt.Sprintf("%s %s", labelStyle.Render("Total billable time:"), util.PrettyMS(s.BillableMs)))
+
+	fmt.Fprintln(c.out)
+	fmt.Fprintln(c.out, lipgloss.PlaceHorizontal(c.terminalWidth, lipgloss.Center, boxStyle.Render(strings.Join(lines, "\n"))))
+	fmt.Fprintln(c.out, lipgloss.PlaceHorizontal(c.terminalWidth, lipgloss.Center, labelStyle.Render(asOf.String())))
+}
+
+// sectionHeader prints a section's title followed by a hint, such as the
+// repository's Actions URL. The hint moves to its own line when both don't
+// fit the terminal, and anything still too wide is cut to fit. --quiet
+// leaves just the title.
+func (c *cardRenderer) sectionHeader(title, hint string) {
+	if c.opts.Quiet {
+		fmt.Fprintln(c.out, c.repoNameStyle.Render(fitWidth(title, c.terminalWidth)))
+		return
+	}
+
+	fmt.Fprintln(c.out)
+	if runewidth.StringWidth(title)+1+runewidth.StringWidth(hint) <= c.terminalWidth {
+		fmt.Fprint(c.out, c.repoNameStyle.Render(title))
+		fmt.Fprintln(c.out, c.repoHintStyle.Render(" "+hint))
+	} else {
+		fmt.Fprintln(c.out, c.repoNameStyle.Render(fitWidth(title, c.terminalWidth)))
+		fmt.Fprintln(c.out, c.repoHintStyle.Render(fitWidth(hint, c.terminalWidth)))
+	}
+	fmt.Fprintln(c.out)
+}
+
+// fitWidth truncates s with an ellipsis so that it is at most width columns.
+func fitWidth(s string, width int) string {
+	if runewidth.StringWidth(s) <= width {
+		return s
+	}
+	if width <= 3 {
+		return runewidth.Truncate(s, width, "")
+	}
+	return truncateWorkflowName(s, width-3)
+}
+
+// repo prints a repository's section: its name and billable subtotal, a card
+// per workflow and, with --artifacts, links to the latest failures.
+// Repositories without workflows are skipped unless --show-empty is set.
+func (c *cardRenderer) repo(r *repositoryData) {
+	if len(r.Workflows) == 0 {
+		if c.opts.ShowEmpty {
+			c.sectionHeader(r.Name, actionsURL(r.Name))
+			fmt.Fprintln(c.out, c.repoHintStyle.Render("No workflows"))
+		}
+		return
+	}
+	hint := actionsURL(r.Name)
+	if ms := r.TotalBillableMs(); ms > 0 {
+		hint += " · " + util.PrettyMS(ms) + " billable"
+	}
+	c.sectionHeader(r.Name, hint)
+
+	cards := []string{}
+	for _, w := range r.Workflows {
+		cards = append(cards, c.cardStyle.Render(w.RenderCard(c.render)))
+	}
+
+	printCardGrid(c.out, cards, c.cardsPerRow)
+
+	if c.opts.Artifacts {
+		for _, w := range r.Workflows {
+			if w.LatestFailure == nil {
+				continue
+			}
+			fmt.Fprintf(c.out, "%s %s %s\n",
+				c.repoNameStyle.Render(w.Name+":"),
+				renderArtifactCount(w.LatestFailure.Artifacts),
+				c.repoHintStyle.Render(w.LatestFailure.HTMLURL))
+		}
+	}
+}
+
+// workflowGroup prints a section per workflow name with a card for each
+// repository that has it, titled with the repository's name, for --group-by
+// workflow.
+func (c *cardRenderer) workflowGroup(g workflowGroup) {
+	c.sectionHeader(g.Name, "in "+util.Pluralize(len(g.Workflows), "repo"))
+
+	cards := []string{}
+	for _, rw := range g.Workflows {
+		w := *rw.Workflow
+		w.Name = rw.Repo
+		cards = append(cards, c.cardStyle.Render(w.RenderCard(c.render)))
+	}
+
+	printCardGrid(c.out, cards, c.cardsPerRow)
+}
+
+// repoGrid prints one summary card per repository, for --repo-cards.
+func (c *cardRenderer) repoGrid(repos []*repositoryData) {
+	cards := []string{}
+	for _, r := range repos {
+		if len(r.Workflows) == 0 {
+			continue
+		}
+		cards = append(cards, c.cardStyle.Render(r.RenderCard()))
+	}
+
+	if !c.opts.Quiet {
+		fmt.Fprintln(c.out)
+	}
+	printCardGrid(c.out, cards, c.cardsPerRow)
+}
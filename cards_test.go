@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCardsNarrowTerminal(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "repositories"},
+		{name: "repo cards", args: []string{"--repo-cards"}},
+		{name: "grouped by workflow", args: []string{"--group-by", "workflow"}},
+	}
+
+	for _, tt := range tests {
+		for _, width := range []string{"1", "5", "30"} {
+			t.Run(tt.name+" at width "+width, func(t *testing.T) {
+				opts := testOptions(t, append([]string{"cli", "--width", width}, tt.args...)...)
+
+				c := newCardRenderer(&bytes.Buffer{}, opts)
+				if c.cardsPerRow != 1 {
+					t.Errorf("got %d cards per row, want 1", c.cardsPerRow)
+				}
+
+				var buf bytes.Buffer
+				if err := renderDashboard(&buf, goldenDashboard(), opts, dataFreshness{}); err != nil {
+					t.Fatal(err)
+				}
+
+				for _, line := range strings.Split(buf.String(), "\n") {
+					if n := strings.Count(line, "╔"); n > 1 {
+						t.Fatalf("got %d cards side by side, want 1:\n%s", n, buf.String())
+					}
+				}
+				if !strings.Contains(buf.String(), "╔") {
+					t.Errorf("no cards rendered:\n%s", buf.String())
+				}
+			})
+		}
+	}
+}
+
+func TestPrintCardGrid(t *testing.T) {
+	tests := []struct {
+		cards       int
+		cardsPerRow int
+		wantRows    int
+	}{
+		{cards: 0, cardsPerRow: 3, wantRows: 0},
+		{cards: 1, cardsPerRow: 3, wantRows: 1},
+		{cards: 3, cardsPerRow: 3, wantRows: 1},
+		{cards: 4, cardsPerRow: 3, wantRows: 2},
+		{cards: 4, cardsPerRow: 1, wantRows: 4},
+	}
+
+	for _, tt := range tests {
+		cards := make([]string, tt.cards)
+		for i := range cards {
+			cards[i] = "x"
+		}
+
+		var buf bytes.Buffer
+		printCardGrid(&buf, cards, tt.cardsPerRow)
+
+		if got := strings.Count(buf.String(), "\n"); got != tt.wantRows {
+			t.Errorf("%d cards, %d per row: got %d rows, want %d", tt.cards, tt.cardsPerRow, got, tt.wantRows)
+		}
+	}
+}
+
+func TestTotalBillableMs(t *testing.T) {
+	tests := []struct {
+		name string
+		repo *repositoryData
+		want int
+	}{
+		{name: "no workflows", repo: &repositoryData{Name: "cli/empty"}},
+		{name: "public", repo: &repositoryData{Name: "cli/cli", Workflows: []*workflow{{Name: "CI"}, {Name: "Nightly"}}}},
+		{
+			name: "summed over workflows",
+			repo: &repositoryData{Name: "cli/internal", Private: true, Workflows: []*workflow{
+				{Name: "CI", BillableMs: 1500},
+				{Name: "Nightly"},
+				{Name: "Deploy", BillableMs: 360000},
+			}},
+			want: 361500,
+		},
+	}
+
+	for _, tt := range tests {
+		if got := tt.repo.TotalBillableMs(); got != tt.want {
+			t.Errorf("%s: got %d ms, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRepoHeaderBillable(t *testing.T) {
+	opts := testOptions(t, "cli")
+	withColor(t, false)
+
+	var buf bytes.Buffer
+	if err := renderDashboard(&buf, goldenDashboard(), opts, dataFreshness{}); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	if !strings.Contains(out, "https://github.com/cli/internal/actions · 6.00m billable") {
+		t.Errorf("no billable subtotal for cli/internal:\n%s", out)
+	}
+	if strings.Contains(out, "https://github.com/cli/cli/actions ·") {
+		t.Errorf("billable subtotal for a repository with none:\n%s", out)
+	}
+}
+
+func TestSectionHeader(t *testing.T) {
+	const (
+		title = "cli/cli"
+		hint  = "https://github.com/cli/cli/actions"
+	)
+
+	tests := []struct {
+		name  string
+		width string
+		quiet bool
+		want  []string
+	}{
+		{name: "fits", width: "80", want: []string{"", "cli/cli https://github.com/cli/cli/actions", ""}},
+		{name: "exactly fits", width: "42", want: []string{"", "cli/cli https://github.com/cli/cli/actions", ""}},
+		{name: "hint on its own line", width: "41", want: []string{"", "cli/cli", "https://github.com/cli/cli/actions", ""}},
+		{name: "hint cut to fit", width: "20", want: []string{"", "cli/cli", "https://github.co...", ""}},
+		{name: "title cut to fit", width: "5", want: []string{"", "cl...", "ht...", ""}},
+		{name: "narrower than an ellipsis", width: "2", want: []string{"", "cl", "ht", ""}},
+		{name: "quiet", width: "80", quiet: true, want: []string{"cli/cli"}},
+		{name: "quiet and narrow", width: "5", quiet: true, want: []string{"cl..."}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := []string{"--width", tt.width, "cli"}
+			if tt.quiet {
+				args = append(args, "--quiet")
+			}
+			opts := testOptions(t, args...)
+			withColor(t, false)
+
+			var buf bytes.Buffer
+			newCardRenderer(&buf, opts).sectionHeader(title, hint)
+
+			got := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuiet(t *testing.T) {
+	suppressed := []string{"GitHub Actions", "Legend:", "https://github.com/cli/a/actions", "Data as of", "Total billable time:"}
+
+	tests := []struct {
+		name string
+		args []string
+		kept []string
+	}{
+		{name: "cards", kept: []string{"cli/a", "Health:", "╔"}},
+		{name: "repo cards", args: []string{"--repo-cards"}, kept: []string{"cli/a", "╔"}},
+		{name: "table", args: []string{"--table"}, kept: []string{"cli/a", "WORKFLOW"}},
+		{name: "run list", args: []string{"--list-runs"}, kept: []string{"cli/a", "success"}},
+	}
+
+	for _, tt := range tests {
+		for _, quiet := range []bool{false, true} {
+			name := tt.name
+			args := append([]string{"--no-cache", "--no-color"}, tt.args...)
+			if quiet {
+				name += " quiet"
+				args = append(args, "-q")
+			}
+
+			t.Run(name, func(t *testing.T) {
+				isolate(t)
+				withColor(t, false)
+				oldColors, oldGlyphs, oldLogger := colors, glyphs, logger
+				defer func() { colors, glyphs, logger = oldColors, oldGlyphs, oldLogger }()
+
+				var stdout, stderr bytes.Buffer
+				if code := runCLI(append(args, "cli"), &stdout, &stderr, func(string) (Fetcher, error) { return dashboardFixture(), nil }); code != 0 {
+					t.Fatalf("got exit code %d; stderr: %s", code, stderr.String())
+				}
+				out := stdout.String()
+
+				for _, s := range tt.kept {
+					if !strings.Contains(out, s) {
+						t.Errorf("output doesn't contain %q:\n%s", s, out)
+					}
+				}
+				if !quiet {
+					if !strings.Contains(out, "GitHub Actions") {
+						t.Errorf("output has no title without --quiet:\n%s", out)
+					}
+					return
+				}
+				for _, s := range suppressed {
+					if strings.Contains(out, s) {
+						t.Errorf("output contains %q with --quiet:\n%s", s, out)
+					}
+				}
+				if strings.HasPrefix(out, "\n") {
+					t.Errorf("output starts with a blank line with --quiet:\n%s", out)
+				}
+				if strings.Contains(out, "\x1b[") {
+					t.Errorf("output has escape sequences with --no-color:\n%q", out)
+				}
+			})
+		}
+	}
+}
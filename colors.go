@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// palette is every color the dashboard renders with.
+type palette struct {
+	Success lipgloss.Color
+	Neutral lipgloss.Color
+	Failed  lipgloss.Color
+	Border  lipgloss.Color
+	Label   lipgloss.Color
+	Slow    lipgloss.Color
+}
+
+var defaultPalette = palette{
+	Success: "#32cd32",
+	Neutral: "#808080",
+	Failed:  "#dc143c",
+	Border:  "63",
+	Label:   "#808080",
+	Slow:    "#ffd700",
+}
+
+const (
+	themeDark       = "dark"
+	themeLight      = "light"
+	themeColorblind = "colorblind"
+)
+
+var validThemes = []string{themeDark, themeLight, themeColorblind}
+
+// themes are the palettes --theme picks from. dark is the default; light
+// uses darker shades that stay readable on a white background, and
+// colorblind swaps green and red for blue and vermillion from the Okabe-Ito
+// palette.
+var themes = map[string]palette{
+	themeDark: defaultPalette,
+	themeLight: {
+		Success: "#008000",
+		Neutral: "#6e6e6e",
+		Failed:  "#c00000",
+		Border:  "25",
+		Label:   "#595959",
+		Slow:    "#b8860b",
+	},
+	themeColorblind: {
+		Success: "#0072b2",
+		Neutral: "#999999",
+		Failed:  "#d55e00",
+		Border:  "63",
+		Label:   "#999999",
+		Slow:    "#f0e442",
+	},
+}
+
+// colors is the palette in use; it is set from options before rendering.
+var colors = defaultPalette
+
+// colorEnabled is false when NO_COLOR or --no-color is set. Styles use it to
+// drop text attributes like bold, which lipgloss emits even without color.
+var colorEnabled = true
+
+// disableColor makes every style render as plain text.
+func disableColor() {
+	colorEnabled = false
+	lipgloss.SetColorProfile(termenv.Ascii)
+}
+
+// elapsedColor colors an average run time: success below slow, Slow up to
+// verySlow and Failed beyond it.
+func elapsedColor(d, slow, verySlow time.Duration) lipgloss.Color {
+	switch {
+	case d >= verySlow:
+		return colors.Failed
+	case d >= slow:
+		return colors.Slow
+	default:
+		return colors.Success
+	}
+}
+
+var hexColorRE = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// parseColor accepts a hex color (#rgb or #rrggbb) or an ANSI color code
+// between 0 and 255.
+func parseColor(value string) (lipgloss.Color, error) {
+	if hexColorRE.MatchString(value) {
+		return lipgloss.Color(value), nil
+	}
+
+	if n, err := strconv.Atoi(value); err == nil && n >= 0 && n <= 255 {
+		return lipgloss.Color(value), nil
+	}
+
+	return "", fmt.Errorf("invalid color '%s'; expected hex (eg #32cd32) or an ANSI code from 0 to 255", value)
+}
+
+// resolveColor picks the value of the named flag, then its environment
+// variable (eg --color-fail and ACTIONS_DASHBOARD_COLOR_FAIL), then the
+// fallback.
+func resolveColor(name, flagValue string, fallback lipgloss.Color) (lipgloss.Color, error) {
+	source := "--" + name
+	value := flagValue
+	if value == "" {
+		source = "ACTIONS_DASHBOARD_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+		value = os.Getenv(source)
+	}
+	if value == "" {
+		return fallback, nil
+	}
+
+	c, err := parseColor(value)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", source, err)
+	}
+
+	return c, nil
+}
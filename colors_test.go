@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestElapsedColor(t *testing.T) {
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		want    string
+	}{
+		{name: "below slow", elapsed: 9 * time.Minute, want: string(colors.Success)},
+		{name: "at slow", elapsed: 10 * time.Minute, want: string(colors.Slow)},
+		{name: "between slow and very slow", elapsed: 20 * time.Minute, want: string(colors.Slow)},
+		{name: "at very slow", elapsed: 30 * time.Minute, want: string(colors.Failed)},
+		{name: "above very slow", elapsed: 2 * time.Hour, want: string(colors.Failed)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := elapsedColor(tt.elapsed, 10*time.Minute, 30*time.Minute); string(got) != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCardElapsedColor(t *testing.T) {
+	ro := renderOptions{SlowThreshold: 5 * time.Minute, VerySlowThreshold: 15 * time.Minute}
+
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		color   bool
+		want    string
+	}{
+		{name: "below slow", elapsed: time.Minute, color: true, want: "\x1b[38;2;50;205;50m1m0s"},
+		{name: "between slow and very slow", elapsed: 10 * time.Minute, color: true, want: "\x1b[38;2;255;215;0m10m0s"},
+		{name: "above very slow", elapsed: 20 * time.Minute, color: true, want: "\x1b[38;2;220;20;60m20m0s"},
+		{name: "color disabled", elapsed: 20 * time.Minute, want: "Avg elapsed: 20m0s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withColor(t, tt.color)
+			old := colors
+			colors = defaultPalette
+			defer func() { colors = old }()
+
+			w := &workflow{Name: "CI", Runs: runsTaking(tt.elapsed)}
+			card := w.RenderCard(ro)
+			if !strings.Contains(card, tt.want) {
+				t.Errorf("card doesn't contain %q:\n%q", tt.want, card)
+			}
+			if !tt.color && strings.Contains(card, "\x1b[") {
+				t.Errorf("card has escape sequences with color disabled:\n%q", card)
+			}
+		})
+	}
+}
+
+func TestRenderOptionsThresholds(t *testing.T) {
+	ro := testOptions(t, "--slow-threshold", "1m", "--very-slow-threshold", "2m", "cli").render()
+	if ro.SlowThreshold != time.Minute || ro.VerySlowThreshold != 2*time.Minute {
+		t.Errorf("got thresholds %s and %s", ro.SlowThreshold, ro.VerySlowThreshold)
+	}
+}
+
+func TestNoColor(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		noColor string
+		want    bool
+	}{
+		{name: "colored", want: true},
+		{name: "--no-color", args: []string{"--no-color"}},
+		{name: "NO_COLOR", noColor: "1"},
+		{name: "--no-color table", args: []string{"--no-color", "--table"}},
+		{name: "--no-color run list", args: []string{"--no-color", "--list-runs"}},
+		{name: "NO_COLOR detailed cards", args: []string{"--detailed", "--emphasize-latest", "--percentiles"}, noColor: "1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			t.Setenv("NO_COLOR", tt.noColor)
+			// As on a terminal, so only the settings under test turn color off.
+			withColor(t, true)
+			oldColors, oldGlyphs, oldLogger := colors, glyphs, logger
+			defer func() { colors, glyphs, logger = oldColors, oldGlyphs, oldLogger }()
+
+			var stdout, stderr bytes.Buffer
+			args := append([]string{"--no-cache"}, append(tt.args, "cli")...)
+			if code := runCLI(args, &stdout, &stderr, func(string) (Fetcher, error) { return dashboardFixture(), nil }); code != 0 {
+				t.Fatalf("got exit code %d; stderr: %s", code, stderr.String())
+			}
+
+			if got := strings.Contains(stdout.String()+stderr.String(), "\x1b["); got != tt.want {
+				t.Errorf("got escape sequences %t, want %t:\n%q", got, tt.want, stdout.String())
+			}
+		})
+	}
+}
+
+// fgSequence is the escape sequence that text in c starts with, in the
+// current color profile.
+func fgSequence(c lipgloss.Color) string {
+	s := lipgloss.NewStyle().Foreground(c).Render("x")
+	return s[:strings.Index(s, "x")]
+}
+
+func TestThemeApplied(t *testing.T) {
+	for _, theme := range validThemes {
+		t.Run(theme, func(t *testing.T) {
+			isolate(t)
+			withColor(t, true)
+			oldColors, oldGlyphs, oldLogger := colors, glyphs, logger
+			defer func() { colors, glyphs, logger = oldColors, oldGlyphs, oldLogger }()
+
+			var stdout, stderr bytes.Buffer
+			args := []string{"--no-cache", "--theme", theme, "cli"}
+			if code := runCLI(args, &stdout, &stderr, func(string) (Fetcher, error) { return dashboardFixture(), nil }); code != 0 {
+				t.Fatalf("got exit code %d; stderr: %s", code, stderr.String())
+			}
+			out := stdout.String()
+
+			pal := themes[theme]
+			for _, c := range []lipgloss.Color{pal.Success, pal.Failed, pal.Label} {
+				if !strings.Contains(out, fgSequence(c)) {
+					t.Errorf("output doesn't use %s from the %s theme:\n%q", c, theme, out)
+				}
+			}
+			if theme != themeDark && strings.Contains(out, fgSequence(defaultPalette.Success)) {
+				t.Errorf("output uses the default success color with the %s theme", theme)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	flag "github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// configPath is where settings are read from when --config isn't given.
+func configPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "actions-dashboard", "config.yml")
+}
+
+// loadConfig reads a YAML file of flag defaults keyed by long flag name, eg
+//
+//	last: 7d
+//	max-runs: 10
+//	repos: [cli, other/go-gh]
+//
+// Values are kept as written, so dates and durations reach the flags' own
+// parsers untouched. A missing file is only an error if required is set.
+func loadConfig(path string, required bool) (map[string]yaml.Node, error) {
+	settings := map[string]yaml.Node{}
+	if path == "" {
+		return settings, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !required {
+		return settings, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read config: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("could not parse config %s: %w", path, err)
+	}
+
+	return settings, nil
+}
+
+// applyConfig sets every flag named in settings that wasn't given on the
+// command line, so flags always win over the file. Lists set repeatable
+// flags once per item.
+func applyConfig(flags *flag.FlagSet, settings map[string]yaml.Node) error {
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if name == "config" || name == "version" || flags.Lookup(name) == nil {
+			return fmt.Errorf("unknown config setting '%s'", name)
+		}
+		if flags.Changed(name) {
+			continue
+		}
+
+		node := settings[name]
+		values := []*yaml.Node{&node}
+		if node.Kind == yaml.SequenceNode {
+			values = node.Content
+		}
+		for _, v := range values {
+			if v.Kind != yaml.ScalarNode {
+				return fmt.Errorf("invalid config setting '%s': expected a value or a list of values", name)
+			}
+			if err := flags.Set(name, v.Value); err != nil {
+				return fmt.Errorf("invalid config setting '%s': %w", name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// conflictingFlags are sets of flags that can't be given together.
+var conflictingFlags = [][]string{
+	{"last", "since"},
+	{"format", "json", "table", "markdown", "md", "html", "csv", "jsonl", "list-runs"},
+	{"branch", "default-branch-only"},
+	{"private-only", "public-only"},
+	{"watch", "stream"},
+	{"watch", "output"},
+}
+
+// clearConfigConflicts puts back the default of every flag the config file
+// set that conflicts with one given on the command line, so that, say,
+// --stream wins over watch: true in the file. Conflicts within the command
+// line, or within the file, are left for parseArgs to report.
+func clearConfigConflicts(flags *flag.FlagSet, onCommandLine map[string]bool) error {
+	for _, names := range conflictingFlags {
+		given := false
+		for _, name := range names {
+			given = given || onCommandLine[name]
+		}
+		if !given {
+			continue
+		}
+
+		for _, name := range names {
+			f := flags.Lookup(name)
+			if onCommandLine[name] || !f.Changed {
+				continue
+			}
+			if err := f.Value.Set(f.DefValue); err != nil {
+				return err
+			}
+			f.Changed = false
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	flag "github.com/spf13/pflag"
+)
+
+// writeConfig writes a config file with content to a temporary directory
+// and returns its path.
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.yml")
+
+	tests := []struct {
+		name     string
+		path     string
+		required bool
+		want     []string
+		wantErr  string
+	}{
+		{name: "no path", path: ""},
+		{name: "missing default file", path: missing},
+		{name: "missing --config file", path: missing, required: true, wantErr: "could not read config"},
+		{name: "settings", path: writeConfig(t, "last: 7d\nrepos: [cli, go-gh]\n"), want: []string{"last", "repos"}},
+		{name: "empty file", path: writeConfig(t, "")},
+		{name: "invalid YAML", path: writeConfig(t, "last: [7d\n"), wantErr: "could not parse config"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings, err := loadConfig(tt.path, tt.required)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(settings) != len(tt.want) {
+				t.Errorf("got %d settings, want %v", len(settings), tt.want)
+			}
+			for _, name := range tt.want {
+				if _, ok := settings[name]; !ok {
+					t.Errorf("missing setting %s", name)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		args    []string
+		check   func(t *testing.T, fs *flag.FlagSet)
+		wantErr string
+	}{
+		{
+			name:   "values and lists",
+			config: "max-runs: 10\nrepos: [cli, go-gh]\n",
+			check: func(t *testing.T, fs *flag.FlagSet) {
+				if got, _ := fs.GetInt("max-runs"); got != 10 {
+					t.Errorf("got max-runs %d", got)
+				}
+				if got, _ := fs.GetStringSlice("repos"); strings.Join(got, " ") != "cli go-gh" {
+					t.Errorf("got repos %v", got)
+				}
+			},
+		},
+		{
+			name:   "command line wins",
+			config: "max-runs: 10\n",
+			args:   []string{"--max-runs", "3"},
+			check: func(t *testing.T, fs *flag.FlagSet) {
+				if got, _ := fs.GetInt("max-runs"); got != 3 {
+					t.Errorf("got max-runs %d", got)
+				}
+			},
+		},
+		{name: "unknown setting", config: "colour: red\n", wantErr: "unknown config setting 'colour'"},
+		{name: "config itself", config: "config: other.yml\n", wantErr: "unknown config setting 'config'"},
+		{name: "invalid value", config: "max-runs: lots\n", wantErr: "invalid config setting 'max-runs'"},
+		{name: "nested value", config: "max-runs: {a: 1}\n", wantErr: "expected a value or a list of values"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.Int("max-runs", 5, "")
+			fs.StringSlice("repos", []string{}, "")
+			fs.String("config", "", "")
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			settings, err := loadConfig(writeConfig(t, tt.config), true)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = applyConfig(fs, settings)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, fs)
+		})
+	}
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		args    []string
+		check   func(t *testing.T, o *options)
+		wantErr string
+	}{
+		{
+			name:   "file supplies defaults",
+			config: "last: 7d\nmax-runs: 10\nrepos: [cli, other/go-gh]\n",
+			check: func(t *testing.T, o *options) {
+				if o.Last != 7*24*time.Hour || o.MaxRuns != 10 || strings.Join(o.Repositories, " ") != "cli other/go-gh" {
+					t.Errorf("got last %s, max runs %d, repos %v", o.Last, o.MaxRuns, o.Repositories)
+				}
+			},
+		},
+		{
+			name:   "flag overrides file",
+			config: "max-runs: 10\nsort: health\n",
+			args:   []string{"-n", "3"},
+			check: func(t *testing.T, o *options) {
+				if o.MaxRuns != 3 || o.Sort != sortHealth {
+					t.Errorf("got max runs %d, sort %s", o.MaxRuns, o.Sort)
+				}
+			},
+		},
+		{
+			name:   "--format over json in the file",
+			config: "json: true\n",
+			args:   []string{"--format", "table"},
+			check: func(t *testing.T, o *options) {
+				if o.Format != formatTable {
+					t.Errorf("got format %s", o.Format)
+				}
+			},
+		},
+		{
+			name:   "shorthand over format in the file",
+			config: "format: csv\n",
+			args:   []string{"--json"},
+			check: func(t *testing.T, o *options) {
+				if o.Format != formatJSON {
+					t.Errorf("got format %s", o.Format)
+				}
+			},
+		},
+		{
+			name:   "--public-only over private-only in the file",
+			config: "private-only: true\n",
+			args:   []string{"--public-only"},
+			check: func(t *testing.T, o *options) {
+				if o.Visibility != visibilityPublic {
+					t.Errorf("got visibility %q", o.Visibility)
+				}
+			},
+		},
+		{
+			name:   "--default-branch-only over branch in the file",
+			config: "branch: main\n",
+			args:   []string{"--default-branch-only"},
+			check: func(t *testing.T, o *options) {
+				if !o.DefaultBranchOnly || o.Branch != "" {
+					t.Errorf("got default branch only %t, branch %q", o.DefaultBranchOnly, o.Branch)
+				}
+			},
+		},
+		{
+			name:   "--stream over watch in the file",
+			config: "watch: true\n",
+			args:   []string{"--stream"},
+			check: func(t *testing.T, o *options) {
+				if !o.Stream || o.Watch {
+					t.Errorf("got stream %t, watch %t", o.Stream, o.Watch)
+				}
+			},
+		},
+		{
+			name:   "--watch over output in the file",
+			config: "output: dashboard.txt\n",
+			args:   []string{"--watch"},
+			check: func(t *testing.T, o *options) {
+				if !o.Watch || o.Output != "" {
+					t.Errorf("got watch %t, output %q", o.Watch, o.Output)
+				}
+			},
+		},
+		{
+			name:   "--last over since in the file",
+			config: "since: 2024-01-01\n",
+			args:   []string{"--last", "7d"},
+			check: func(t *testing.T, o *options) {
+				if !o.Since.IsZero() || o.Last != 7*24*time.Hour {
+					t.Errorf("got since %s, last %s", o.Since, o.Last)
+				}
+			},
+		},
+		{
+			name:   "--since over last in the file",
+			config: "last: 7d\n",
+			args:   []string{"--since", "2024-01-01"},
+			check: func(t *testing.T, o *options) {
+				if o.Since.IsZero() {
+					t.Error("--since was dropped")
+				}
+			},
+		},
+		{name: "conflict within the file", config: "watch: true\nstream: true\n", wantErr: "--watch and --stream cannot be combined"},
+		{name: "formats conflicting within the file", config: "json: true\nformat: table\n", wantErr: "--json cannot be combined with another --format"},
+		{name: "conflict on the command line", config: "max-runs: 10\n", args: []string{"--watch", "--stream"}, wantErr: "--watch and --stream cannot be combined"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			args := append([]string{"--config", writeConfig(t, tt.config)}, tt.args...)
+			o, err := parseArgs(append(args, "cli"))
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, o)
+		})
+	}
+}
+
+func TestDefaultConfigPath(t *testing.T) {
+	isolate(t)
+	path := configPath()
+	if !strings.HasPrefix(path, os.Getenv("XDG_CONFIG_HOME")) {
+		t.Skip("the user config directory doesn't follow $XDG_CONFIG_HOME here")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("max-runs: 12\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	o, err := parseArgs([]string{"cli"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.MaxRuns != 12 {
+		t.Errorf("got max runs %d, want 12 from the default config file", o.MaxRuns)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// utf8BOM lets Excel detect that the file is UTF-8 rather than the
+// system codepage.
+const utf8BOM = "\xef\xbb\xbf"
+
+var csvHeader = []string{
+	"owner",
+	"repo",
+	"private",
+	"workflow",
+	"runs_analyzed",
+	"success_rate",
+	"avg_elapsed_seconds",
+	"billable_ms",
+}
+
+// writeCSV emits one row per workflow.
+func writeCSV(out io.Writer, repos []*repositoryData, bom bool) error {
+	if bom {
+		if _, err := io.WriteString(out, utf8BOM); err != nil {
+			return err
+		}
+	}
+
+	cw := csv.NewWriter(out)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, r := range repos {
+		owner, name := splitRepo("", r.Name)
+
+		for _, w := range r.Workflows {
+			_, _, pct := w.SuccessRate()
+			err := cw.Write([]string{
+				owner,
+				name,
+				strconv.FormatBool(r.Private),
+				w.Name,
+				strconv.Itoa(len(w.Runs)),
+				strconv.FormatFloat(pct, 'f', 1, 64),
+				strconv.Itoa(int(w.AverageElapsed().Seconds())),
+				strconv.Itoa(w.BillableMs),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+)
+
+func TestWriteCSVGolden(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeCSV(&buf, goldenDashboard(), false); err != nil {
+		t.Fatal(err)
+	}
+
+	checkGolden(t, "dashboard.golden.csv", buf.Bytes())
+}
+
+func TestWriteCSVEscapesNames(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeCSV(&buf, goldenDashboard(), false); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("got %d records, want a header and 3 workflows", len(records))
+	}
+	if got := records[3][3]; got != `Deploy "prod", <eu>` {
+		t.Errorf("got workflow %q", got)
+	}
+}
+
+func TestWriteCSVBOM(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeCSV(&buf, goldenDashboard(), true); err != nil {
+		t.Fatal(err)
+	}
+
+	got := buf.Bytes()
+	if !bytes.HasPrefix(got, []byte(utf8BOM)) {
+		t.Fatalf("output doesn't start with a byte order mark: %q", got[:8])
+	}
+	checkGolden(t, "dashboard.golden.csv", got[len(utf8BOM):])
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// plannedCall is a request the dashboard would make. Braces in Path stand
+// for values only the API can tell us, and Each says what the request is
+// repeated for: "" for once, or "repository", "workflow" or "run".
+type plannedCall struct {
+	Path string
+	Each string
+	Note string
+}
+
+// planCalls lists the requests fetching the dashboard, or the --inventory,
+// makes for opts, in the order they happen, without making any of them.
+func planCalls(opts *options) []plannedCall {
+	calls := []plannedCall{}
+
+	if len(opts.Repositories) > 0 {
+		for _, repoName := range opts.Repositories {
+			owner, name := splitRepo(opts.Selector, repoName)
+			calls = append(calls, plannedCall{Path: fmt.Sprintf("repos/%s/%s", owner, name)})
+		}
+	} else {
+		for _, selector := range opts.Selectors {
+			calls = append(calls, plannedCall{
+				Path: fmt.Sprintf("orgs/%s/repos?per_page=100", selector),
+				Note: fmt.Sprintf("one per 100 repositories; users/%s/repos instead if not an org", selector),
+			})
+		}
+	}
+
+	calls = append(calls, plannedCall{Path: "repos/{repo}/actions/workflows", Each: "repository"})
+
+	if opts.Inventory {
+		// getInventory ignores the run filters and fetches no timings or
+		// artifacts.
+		return append(calls, plannedCall{
+			Path: "repos/{repo}/actions/workflows/{id}/runs?per_page=1",
+			Each: "workflow",
+			Note: "latest run only",
+		})
+	}
+
+	runsPath := "repos/{repo}/actions/workflows/{id}/runs"
+	query := []string{fmt.Sprintf("per_page=%d", runsPerPage(runsFetched(opts)))}
+	if opts.DefaultBranchOnly {
+		query = append(query, "branch={default_branch}")
+	}
+	if q := runsQuery(opts); q != "" {
+		query = append(query, q)
+	}
+	runsPath += "?" + strings.Join(query, "&")
+	runsCall := plannedCall{Path: runsPath, Each: "workflow"}
+	if n := runsFetched(opts); n > 100 {
+		runsCall.Note = fmt.Sprintf("up to %d pages for %d runs", (n+99)/100, n)
+	}
+	calls = append(calls, runsCall)
+
+	if !opts.NoBillable {
+		calls = append(calls, plannedCall{
+			Path: "repos/{repo}/actions/runs/{id}/timing",
+			Each: "run",
+			Note: "private repositories only",
+		})
+	}
+
+	if opts.Artifacts {
+		calls = append(calls, plannedCall{
+			Path: "repos/{repo}/actions/runs/{id}/artifacts",
+			Each: "workflow",
+			Note: "latest failed run only",
+		})
+	}
+
+	return calls
+}
+
+// callCounts are the symbols estimateCalls uses for counts only the API
+// knows.
+var callCounts = []struct{ each, symbol, meaning string }{
+	{"repository", "R", "repositories"},
+	{"workflow", "W", "workflows"},
+	{"run", "P", "runs in private repositories"},
+}
+
+// estimateCalls sums calls into a formula over callCounts, eg "2 + R + W",
+// along with what each symbol in it stands for.
+func estimateCalls(calls []plannedCall) (formula string, symbols []string) {
+	fixed := 0
+	per := map[string]int{}
+	for _, c := range calls {
+		if c.Each == "" {
+			fixed++
+		} else {
+			per[c.Each]++
+		}
+	}
+
+	terms := []string{fmt.Sprint(fixed)}
+	for _, c := range callCounts {
+		n := per[c.each]
+		if n == 0 {
+			continue
+		}
+		if n == 1 {
+			terms = append(terms, c.symbol)
+		} else {
+			terms = append(terms, fmt.Sprintf("%d%s", n, c.symbol))
+		}
+		symbols = append(symbols, fmt.Sprintf("%s %s", c.symbol, c.meaning))
+	}
+
+	return strings.Join(terms, " + "), symbols
+}
+
+// printPlan describes planCalls for --dry-run.
+func printPlan(out io.Writer, opts *options) {
+	calls := planCalls(opts)
+
+	fmt.Fprintln(out, "Dry run; no API calls were made. Fetching the dashboard would call:")
+	fmt.Fprintln(out)
+	for _, c := range calls {
+		line := "  GET " + c.Path
+		if c.Each != "" {
+			line += " (each " + c.Each
+			if c.Note != "" {
+				line += ", " + c.Note
+			}
+			line += ")"
+		} else if c.Note != "" {
+			line += " (" + c.Note + ")"
+		}
+		fmt.Fprintln(out, line)
+	}
+	fmt.Fprintln(out)
+	formula, symbols := estimateCalls(calls)
+	fmt.Fprintf(out, "Estimated calls: %s, with %s %s\n", formula, strings.Join(symbols, ", "), opts.period())
+	fmt.Fprintf(out, "At most %d calls run at once (--max-concurrency)\n", opts.MaxConcurrency)
+}
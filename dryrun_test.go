@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPlanCalls(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []plannedCall
+	}{
+		{
+			name: "owners",
+			args: []string{"cli", "other"},
+			want: []plannedCall{
+				{Path: "orgs/cli/repos?per_page=100", Note: "one per 100 repositories; users/cli/repos instead if not an org"},
+				{Path: "orgs/other/repos?per_page=100", Note: "one per 100 repositories; users/other/repos instead if not an org"},
+				{Path: "repos/{repo}/actions/workflows", Each: "repository"},
+				{Path: "repos/{repo}/actions/workflows/{id}/runs?per_page=30", Each: "workflow"},
+				{Path: "repos/{repo}/actions/runs/{id}/timing", Each: "run", Note: "private repositories only"},
+			},
+		},
+		{
+			name: "named repositories with filters",
+			args: []string{"-r", "cli,other/go-gh", "--default-branch-only", "--actor", "monalisa", "--no-billable", "--artifacts", "-n", "250", "cli"},
+			want: []plannedCall{
+				{Path: "repos/cli/cli"},
+				{Path: "repos/other/go-gh"},
+				{Path: "repos/{repo}/actions/workflows", Each: "repository"},
+				{Path: "repos/{repo}/actions/workflows/{id}/runs?per_page=100&branch={default_branch}&actor=monalisa", Each: "workflow", Note: "up to 3 pages for 250 runs"},
+				{Path: "repos/{repo}/actions/runs/{id}/artifacts", Each: "workflow", Note: "latest failed run only"},
+			},
+		},
+		{
+			name: "inventory",
+			args: []string{"--inventory", "--actor", "monalisa", "--artifacts", "cli"},
+			want: []plannedCall{
+				{Path: "orgs/cli/repos?per_page=100", Note: "one per 100 repositories; users/cli/repos instead if not an org"},
+				{Path: "repos/{repo}/actions/workflows", Each: "repository"},
+				{Path: "repos/{repo}/actions/workflows/{id}/runs?per_page=1", Each: "workflow", Note: "latest run only"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := planCalls(testOptions(t, tt.args...))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEstimateCalls(t *testing.T) {
+	formula, symbols := estimateCalls([]plannedCall{
+		{Path: "repos/cli/cli"},
+		{Path: "repos/cli/go-gh"},
+		{Path: "repos/{repo}/actions/workflows", Each: "repository"},
+		{Path: "repos/{repo}/actions/workflows/{id}/runs", Each: "workflow"},
+		{Path: "repos/{repo}/actions/runs/{id}/artifacts", Each: "workflow"},
+	})
+	if formula != "2 + R + 2W" {
+		t.Errorf("got formula %q", formula)
+	}
+	if want := []string{"R repositories", "W workflows"}; !reflect.DeepEqual(symbols, want) {
+		t.Errorf("got symbols %q, want %q", symbols, want)
+	}
+}
+
+func TestPrintPlanInventory(t *testing.T) {
+	var buf bytes.Buffer
+	printPlan(&buf, testOptions(t, "--inventory", "cli"))
+
+	if !strings.Contains(buf.String(), "GET repos/{repo}/actions/workflows/{id}/runs?per_page=1 (each workflow, latest run only)") {
+		t.Errorf("plan doesn't fetch the latest run only:\n%s", buf.String())
+	}
+	if strings.Contains(buf.String(), "timing") {
+		t.Errorf("plan fetches timings for the inventory:\n%s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "Estimated calls: 1 + R + W,") {
+		t.Errorf("got estimate:\n%s", buf.String())
+	}
+}
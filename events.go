@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/vilmibm/actions-dashboard/util"
+)
+
+type eventTotals struct {
+	Event      string
+	Runs       int
+	Elapsed    time.Duration
+	BillableMs int
+}
+
+// summarizeEvents aggregates every run across repos by the event that
+// triggered it, busiest events first.
+func summarizeEvents(repos []*repositoryData) []eventTotals {
+	byEvent := map[string]*eventTotals{}
+
+	for _, r := range repos {
+		for _, w := range r.Workflows {
+			for _, rr := range w.Runs {
+				event := rr.Event
+				if event == "" {
+					event = "unknown"
+				}
+				t, ok := byEvent[event]
+				if !ok {
+					t = &eventTotals{Event: event}
+					byEvent[event] = t
+				}
+				t.Runs++
+				t.Elapsed += rr.Elapsed
+				t.BillableMs += rr.BillableMs
+			}
+		}
+	}
+
+	out := []eventTotals{}
+	for _, t := range byEvent {
+		out = append(out, *t)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Runs != out[j].Runs {
+			return out[i].Runs > out[j].Runs
+		}
+		return out[i].Event < out[j].Event
+	})
+
+	return out
+}
+
+func renderEventSummary(out io.Writer, repos []*repositoryData, opts *options) error {
+	fmt.Fprintf(out, "Runs by trigger event for %s %s\n\n", opts.owners(), opts.period())
+
+	totals := summarizeEvents(repos)
+	if len(totals) == 0 {
+		fmt.Fprintln(out, "No runs")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "EVENT\tRUNS\tELAPSED\tBILLABLE")
+	for _, t := range totals {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", t.Event, t.Runs, t.Elapsed, util.PrettyMS(t.BillableMs))
+	}
+
+	return tw.Flush()
+}
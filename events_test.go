@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSummarizeEvents(t *testing.T) {
+	triggered := func(event string, elapsed time.Duration, billableMs int) run {
+		return run{Status: "completed", Conclusion: "success", Event: event, Elapsed: elapsed, BillableMs: billableMs}
+	}
+	repos := []*repositoryData{
+		{Name: "cli/a", Workflows: []*workflow{
+			{Name: "CI", Runs: []run{
+				triggered("push", time.Minute, 60000),
+				triggered("pull_request", 2*time.Minute, 0),
+				triggered("push", 3*time.Minute, 1000),
+			}},
+			{Name: "Nightly", Runs: []run{triggered("schedule", time.Hour, 0)}},
+		}},
+		{Name: "cli/b", Workflows: []*workflow{
+			{Name: "CI", Runs: []run{
+				triggered("pull_request", time.Minute, 500),
+				triggered("push", time.Minute, 0),
+				triggered("", 10*time.Second, 0),
+			}},
+			{Name: "Idle"},
+		}},
+	}
+
+	// Busiest first, ties by name.
+	want := []eventTotals{
+		{Event: "push", Runs: 3, Elapsed: 5 * time.Minute, BillableMs: 61000},
+		{Event: "pull_request", Runs: 2, Elapsed: 3 * time.Minute, BillableMs: 500},
+		{Event: "schedule", Runs: 1, Elapsed: time.Hour},
+		{Event: "unknown", Runs: 1, Elapsed: 10 * time.Second},
+	}
+	if got := summarizeEvents(repos); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestRenderEventSummary(t *testing.T) {
+	opts := testOptions(t, "--format", "event-summary", "--last", "7d", "cli")
+
+	var buf bytes.Buffer
+	if err := renderEventSummary(&buf, goldenDashboard(), opts); err != nil {
+		t.Fatal(err)
+	}
+	want := `Runs by trigger event for cli for the past 1 week
+
+EVENT  RUNS  ELAPSED  BILLABLE
+push   8     22m40s   6.00m
+`
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := renderEventSummary(&buf, []*repositoryData{{Name: "cli/empty"}}, opts); err != nil {
+		t.Fatal(err)
+	}
+	if want := "Runs by trigger event for cli for the past 1 week\n\nNo runs\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// Fetcher is where the dashboard's data comes from. ghFetcher reads it from
+// the GitHub API; anything else that can answer the same questions, such as
+// canned fixtures, can stand in for it.
+type Fetcher interface {
+	// Repo fetches a single repository.
+	Repo(ctx context.Context, owner, name string) (*repositoryData, error)
+	// Repos fetches up to limit repositories of an organization or user, or
+	// all of them when limit isn't positive.
+	Repos(ctx context.Context, owner string, limit int) ([]*repositoryData, error)
+	// Workflows lists every workflow in a repository, disabled ones included.
+	Workflows(ctx context.Context, repo string) ([]workflowsPayload, error)
+	// Runs fetches up to limit of a workflow's most recent runs, newest
+	// first, narrowed by a query as built by runsQuery.
+	Runs(ctx context.Context, w workflowsPayload, query string, limit int) ([]runPayload, error)
+	// Timing fetches how much billable time a run used on each operating
+	// system.
+	Timing(ctx context.Context, r run) (billablePayload, error)
+	// Artifacts counts the artifacts a run uploaded.
+	Artifacts(ctx context.Context, r run) (int, error)
+}
+
+// ghFetcher is the Fetcher backed by the GitHub REST API. Every call goes
+// through apiGet, so it shares restClient, apiLimiter and apiBudget.
+type ghFetcher struct{}
+
+func (ghFetcher) Repo(ctx context.Context, owner, name string) (*repositoryData, error) {
+	var data repositoryData
+	if err := apiGet(ctx, fmt.Sprintf("repos/%s/%s", owner, name), &data); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+// Repos tries owner as an organization first and then, if there is no such
+// organization, as a user. Any other failure, such as running out of API
+// calls or hitting the rate limit, is returned as is.
+func (ghFetcher) Repos(ctx context.Context, owner string, limit int) ([]*repositoryData, error) {
+	result, orgErr := getAllRepos(ctx, fmt.Sprintf("orgs/%s/repos", owner), limit)
+	if orgErr == nil {
+		return result, nil
+	}
+	if !isNotFound(orgErr) {
+		return nil, orgErr
+	}
+	result, userErr := getAllRepos(ctx, fmt.Sprintf("users/%s/repos", owner), limit)
+	if isNotFound(userErr) {
+		return nil, fmt.Errorf("could not find a user or org called '%s'", owner)
+	} else if userErr != nil {
+		return nil, userErr
+	}
+	return result, nil
+}
+
+func (ghFetcher) Workflows(ctx context.Context, repo string) ([]workflowsPayload, error) {
+	var p struct {
+		Workflows []workflowsPayload
+	}
+	if err := apiGet(ctx, fmt.Sprintf("repos/%s/actions/workflows", repo), &p); err != nil {
+		return nil, err
+	}
+
+	return p.Workflows, nil
+}
+
+// Runs follows pagination, 100 runs a page at most, until limit runs are
+// fetched or there are no more.
+func (ghFetcher) Runs(ctx context.Context, w workflowsPayload, query string, limit int) ([]runPayload, error) {
+	next := fmt.Sprintf("%s/runs?per_page=%d", w.URL, runsPerPage(limit))
+	if query != "" {
+		next += "&" + query
+	}
+
+	runs := []runPayload{}
+	for next != "" && len(runs) < limit {
+		var page struct {
+			WorkflowRuns []runPayload `json:"workflow_runs"`
+		}
+		var err error
+		if next, err = apiGetPage(ctx, next, &page); err != nil {
+			return nil, err
+		}
+		runs = append(runs, page.WorkflowRuns...)
+	}
+
+	if len(runs) > limit {
+		runs = runs[:limit]
+	}
+	return runs, nil
+}
+
+// runsPerPage is the page size for fetching limit runs; the API allows 100
+// at most.
+func runsPerPage(limit int) int {
+	if limit > 100 {
+		return 100
+	}
+	return limit
+}
+
+func (ghFetcher) Timing(ctx context.Context, r run) (billablePayload, error) {
+	var timing struct {
+		Billable billablePayload
+	}
+	if err := apiGet(ctx, fmt.Sprintf("%s/timing", r.URL), &timing); err != nil {
+		return billablePayload{}, err
+	}
+
+	return timing.Billable, nil
+}
+
+func (ghFetcher) Artifacts(ctx context.Context, r run) (int, error) {
+	var artifacts struct {
+		TotalCount int `json:"total_count"`
+	}
+	if err := apiGet(ctx, fmt.Sprintf("%s/artifacts", r.URL), &artifacts); err != nil {
+		return 0, err
+	}
+
+	return artifacts.TotalCount, nil
+}
+
+// getAllRepos follows pagination until every repository is fetched, or
+// until limit repositories are when limit is positive.
+func getAllRepos(ctx context.Context, path string, limit int) ([]*repositoryData, error) {
+	repoData := []*repositoryData{}
+	next := path + "?per_page=100"
+
+	for next != "" {
+		page := []*repositoryData{}
+		var err error
+		if next, err = apiGetPage(ctx, next, &page); err != nil {
+			return nil, err
+		}
+
+		repoData = append(repoData, page...)
+
+		if limit > 0 && len(repoData) >= limit {
+			return repoData[:limit], nil
+		}
+	}
+
+	return repoData, nil
+}
@@ -0,0 +1,1042 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cli/go-gh/pkg/api"
+)
+
+// fakeFetcher is a Fetcher over canned data. Errors can be planted for any
+// call, keyed by what it's about: "repo:owner/name", "repos:owner",
+// "workflows:owner/name", "runs:<workflow URL>" or "timing:<run URL>".
+type fakeFetcher struct {
+	owners    map[string][]*repositoryData
+	workflows map[string][]workflowsPayload
+	runs      map[string][]runPayload
+	timings   map[string]billablePayload
+	errs      map[string]error
+	// slow delays a call, keyed as for errs, until it's cancelled.
+	slow map[string]bool
+	// queries holds the runs query last asked for, by workflow URL.
+	queries map[string]string
+
+	mu    sync.Mutex
+	calls []string
+}
+
+func newFakeFetcher() *fakeFetcher {
+	return &fakeFetcher{
+		owners:    map[string][]*repositoryData{},
+		workflows: map[string][]workflowsPayload{},
+		runs:      map[string][]runPayload{},
+		timings:   map[string]billablePayload{},
+		errs:      map[string]error{},
+		slow:      map[string]bool{},
+		queries:   map[string]string{},
+	}
+}
+
+var errNotFoundForTest = api.HTTPError{StatusCode: http.StatusNotFound, Message: "Not Found"}
+
+// addRepo adds a repository, named owner/name, to its owner.
+func (f *fakeFetcher) addRepo(fullName string, private bool) *repositoryData {
+	owner, _ := splitRepo("", fullName)
+	r := &repositoryData{Name: fullName, Private: private, DefaultBranch: "main"}
+	f.owners[owner] = append(f.owners[owner], r)
+	f.workflows[fullName] = []workflowsPayload{}
+	return r
+}
+
+// addWorkflow adds an active workflow with runs, newest first, to a
+// repository.
+func (f *fakeFetcher) addWorkflow(repo, name string, runs ...runPayload) workflowsPayload {
+	id := len(f.workflows[repo]) + 1
+	w := workflowsPayload{
+		Id:    id,
+		State: "active",
+		Name:  name,
+		Path:  fmt.Sprintf(".github/workflows/%s.yml", strings.ToLower(name)),
+		URL:   fmt.Sprintf("https://api.github.com/repos/%s/actions/workflows/%d", repo, id),
+	}
+	f.workflows[repo] = append(f.workflows[repo], w)
+	for i := range runs {
+		if runs[i].URL == "" {
+			runs[i].URL = fmt.Sprintf("https://api.github.com/repos/%s/actions/runs/%d%02d", repo, id, i)
+		}
+	}
+	f.runs[w.URL] = runs
+	return w
+}
+
+// completedRun is a run that finished ago before now, after elapsed.
+func completedRun(conclusion string, ago, elapsed time.Duration) runPayload {
+	finished := time.Now().Add(-ago)
+	return runPayload{
+		Status:       "completed",
+		Conclusion:   conclusion,
+		CreatedAt:    finished.Add(-elapsed),
+		RunStartedAt: finished.Add(-elapsed),
+		UpdatedAt:    finished,
+	}
+}
+
+// call records a call and returns its planted error, waiting for ctx first
+// if the call is slow.
+func (f *fakeFetcher) call(ctx context.Context, key string) error {
+	f.mu.Lock()
+	f.calls = append(f.calls, key)
+	f.mu.Unlock()
+
+	if f.slow[key] {
+		<-ctx.Done()
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return f.errs[key]
+}
+
+func (f *fakeFetcher) Repo(ctx context.Context, owner, name string) (*repositoryData, error) {
+	key := owner + "/" + name
+	if err := f.call(ctx, "repo:"+key); err != nil {
+		return nil, err
+	}
+	for _, r := range f.owners[owner] {
+		if r.Name == key {
+			copied := *r
+			return &copied, nil
+		}
+	}
+	return nil, errNotFoundForTest
+}
+
+func (f *fakeFetcher) Repos(ctx context.Context, owner string, limit int) ([]*repositoryData, error) {
+	if err := f.call(ctx, "repos:"+owner); err != nil {
+		return nil, err
+	}
+	repos, ok := f.owners[owner]
+	if !ok {
+		return nil, fmt.Errorf("could not find a user or org called '%s'", owner)
+	}
+	out := []*repositoryData{}
+	for _, r := range repos {
+		copied := *r
+		out = append(out, &copied)
+	}
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (f *fakeFetcher) Workflows(ctx context.Context, repo string) ([]workflowsPayload, error) {
+	if err := f.call(ctx, "workflows:"+repo); err != nil {
+		return nil, err
+	}
+	return f.workflows[repo], nil
+}
+
+func (f *fakeFetcher) Runs(ctx context.Context, w workflowsPayload, query string, limit int) ([]runPayload, error) {
+	f.mu.Lock()
+	f.queries[w.URL] = query
+	f.mu.Unlock()
+	if err := f.call(ctx, "runs:"+w.URL); err != nil {
+		return nil, err
+	}
+	runs := f.runs[w.URL]
+	if len(runs) > limit {
+		runs = runs[:limit]
+	}
+	return runs, nil
+}
+
+func (f *fakeFetcher) Timing(ctx context.Context, r run) (billablePayload, error) {
+	if err := f.call(ctx, "timing:"+r.URL); err != nil {
+		return billablePayload{}, err
+	}
+	return f.timings[r.URL], nil
+}
+
+func (f *fakeFetcher) Artifacts(ctx context.Context, r run) (int, error) {
+	if err := f.call(ctx, "artifacts:"+r.URL); err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+// called reports how many calls were made with key.
+func (f *fakeFetcher) called(key string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	n := 0
+	for _, c := range f.calls {
+		if c == key {
+			n++
+		}
+	}
+	return n
+}
+
+// testOptions parses args as given on the command line, away from the
+// user's config and cache, and clears any warnings left by other tests.
+func testOptions(t *testing.T, args ...string) *options {
+	t.Helper()
+	isolate(t)
+	warnings.flush(io.Discard)
+	t.Cleanup(func() { warnings.flush(io.Discard) })
+
+	opts, err := parseArgs(args)
+	if err != nil {
+		t.Fatalf("could not parse %v: %s", args, err)
+	}
+	return opts
+}
+
+func repoNames(repos []*repositoryData) []string {
+	names := []string{}
+	for _, r := range repos {
+		names = append(names, r.Name)
+	}
+	return names
+}
+
+func TestPopulateReposFromOwners(t *testing.T) {
+	f := newFakeFetcher()
+	f.addRepo("cli/zeta", false)
+	f.addRepo("cli/alpha", true)
+	f.addRepo("cli/old", false).Archived = true
+	f.addRepo("other/beta", false)
+
+	opts := testOptions(t, "cli", "other", "cli")
+	repos, err := populateRepos(context.Background(), f, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "cli/alpha cli/zeta other/beta"
+	if got := strings.Join(repoNames(repos), " "); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestPopulateReposUnknownOwner(t *testing.T) {
+	f := newFakeFetcher()
+	opts := testOptions(t, "nobody")
+
+	_, err := populateRepos(context.Background(), f, opts)
+	if err == nil || !strings.Contains(err.Error(), "nobody") {
+		t.Errorf("got %v, want an error naming the owner", err)
+	}
+}
+
+func TestPopulateReposSkipsMissing(t *testing.T) {
+	f := newFakeFetcher()
+	f.addRepo("cli/cli", false)
+	f.addRepo("other/go-gh", false)
+
+	opts := testOptions(t, "-r", "cli,typo,other/go-gh", "cli")
+	repos, err := populateRepos(context.Background(), f, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Join(repoNames(repos), " "); got != "cli/cli other/go-gh" {
+		t.Errorf("got %s", got)
+	}
+	if got := warnings.list(); len(got) != 1 || got[0] != "skipped: repository cli/typo not found" {
+		t.Errorf("got warnings %q", got)
+	}
+}
+
+func TestPopulateReposStrict(t *testing.T) {
+	f := newFakeFetcher()
+	f.addRepo("cli/cli", false)
+
+	opts := testOptions(t, "--strict", "-r", "cli,typo", "cli")
+	_, err := populateRepos(context.Background(), f, opts)
+	if err == nil || err.Error() != "repository cli/typo not found" {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestPopulateReposCutShort(t *testing.T) {
+	f := newFakeFetcher()
+	f.addRepo("cli/a", false)
+	f.addRepo("cli/b", false)
+	f.addRepo("cli/c", false)
+	f.errs["repo:cli/b"] = errAPIBudget
+
+	opts := testOptions(t, "--max-api-calls", "1", "-r", "a,b,c", "cli")
+	repos, err := populateRepos(context.Background(), f, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Join(repoNames(repos), " "); got != "cli/a" {
+		t.Errorf("got %s", got)
+	}
+	if f.called("repo:cli/c") != 0 {
+		t.Error("kept fetching after the budget ran out")
+	}
+	if got := warnings.list(); len(got) != 1 || !strings.Contains(got[0], "--max-api-calls") {
+		t.Errorf("got warnings %q", got)
+	}
+}
+
+// dashboardFixture is three repositories with two workflows each.
+func dashboardFixture() *fakeFetcher {
+	f := newFakeFetcher()
+	for _, repo := range []string{"cli/a", "cli/b", "cli/c"} {
+		f.addRepo(repo, false)
+		f.addWorkflow(repo, "CI",
+			completedRun("success", time.Hour, time.Minute),
+			completedRun("failure", 2*time.Hour, 2*time.Minute))
+		f.addWorkflow(repo, "Release", completedRun("success", time.Hour, time.Minute))
+	}
+	return f
+}
+
+func TestFetchDashboardEach(t *testing.T) {
+	f := dashboardFixture()
+	f.addRepo("cli/no-actions", false)
+	f.errs["workflows:cli/no-actions"] = errNotFoundForTest
+
+	opts := testOptions(t, "-c", "4", "--max-concurrency", "4", "cli")
+	var handed []string
+	repos, err := fetchDashboardEach(context.Background(), f, opts, func(r *repositoryData) {
+		handed = append(handed, r.Name)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "cli/a cli/b cli/c cli/no-actions"
+	if got := strings.Join(handed, " "); got != want {
+		t.Errorf("handed %s, want %s in order", got, want)
+	}
+	for _, r := range repos {
+		want := 2
+		if r.Name == "cli/no-actions" {
+			want = 0
+		}
+		if len(r.Workflows) != want {
+			t.Errorf("%s: got %d workflows, want %d", r.Name, len(r.Workflows), want)
+		}
+	}
+	if w := repos[0].Workflows[0]; w.Name != "CI" || len(w.Runs) != 2 || w.Runs[1].Conclusion != "failure" {
+		t.Errorf("got workflow %+v", w)
+	}
+}
+
+func TestFetchDashboardEachFailure(t *testing.T) {
+	f := dashboardFixture()
+	f.errs["workflows:cli/b"] = errors.New("boom")
+
+	opts := testOptions(t, "cli")
+	var handed []string
+	_, err := fetchDashboardEach(context.Background(), f, opts, func(r *repositoryData) {
+		handed = append(handed, r.Name)
+	})
+	if err == nil || err.Error() != "boom" {
+		t.Fatalf("got %v, want boom", err)
+	}
+	if got := strings.Join(handed, " "); got != "cli/a" {
+		t.Errorf("handed %s, want only the repository before the failure", got)
+	}
+}
+
+func TestFetchDashboardEachRunsFailure(t *testing.T) {
+	f := dashboardFixture()
+	w := f.workflows["cli/c"][1]
+	f.errs["runs:"+w.URL] = errors.New("boom")
+
+	opts := testOptions(t, "cli")
+	_, err := fetchDashboardEach(context.Background(), f, opts, nil)
+	if err == nil || err.Error() != "could not fetch runs: boom" {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestFetchDashboardEachBudgetCutShort(t *testing.T) {
+	f := dashboardFixture()
+	w := f.workflows["cli/b"][1]
+	f.errs["runs:"+w.URL] = errAPIBudget
+
+	opts := testOptions(t, "--max-api-calls", "10", "cli")
+	repos, err := fetchDashboardEach(context.Background(), f, opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Whatever was fetched is kept: all of a and c, and b's first workflow.
+	got := []int{}
+	for _, r := range repos {
+		got = append(got, len(r.Workflows))
+	}
+	if fmt.Sprint(got) != "[2 1 2]" {
+		t.Errorf("got workflow counts %v", got)
+	}
+	if msgs := warnings.list(); len(msgs) != 1 || !strings.Contains(msgs[0], "10 API calls (--max-api-calls)") {
+		t.Errorf("got warnings %q", msgs)
+	}
+}
+
+func TestFetchDashboardEachTimeout(t *testing.T) {
+	f := dashboardFixture()
+	w := f.workflows["cli/c"][0]
+	f.slow["runs:"+w.URL] = true
+
+	opts := testOptions(t, "--timeout", "50ms", "cli")
+	ctx, cancel := opts.fetchContext(context.Background())
+	defer cancel()
+
+	repos, err := fetchDashboardEach(ctx, f, opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(repos) != 3 || len(repos[0].Workflows) != 2 || len(repos[2].Workflows) != 1 {
+		t.Errorf("got repos %v", repoNames(repos))
+	}
+	if msgs := warnings.list(); len(msgs) != 1 || !strings.Contains(msgs[0], "after 50ms (--timeout)") {
+		t.Errorf("got warnings %q", msgs)
+	}
+}
+
+func TestFetchDashboardEachTimeoutListingRepos(t *testing.T) {
+	f := dashboardFixture()
+	f.slow["repos:cli"] = true
+
+	opts := testOptions(t, "--timeout", "10ms", "cli")
+	ctx, cancel := opts.fetchContext(context.Background())
+	defer cancel()
+
+	_, err := fetchDashboardEach(ctx, f, opts, nil)
+	if err == nil || err.Error() != "timed out after 10ms (--timeout) fetching repository data" {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestRunCLIWithFetcher(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		want       int
+		wantStdout string
+		wantStderr string
+	}{
+		{name: "dashboard", args: []string{"--no-cache", "--table", "cli"}, want: 0, wantStdout: "cli/a"},
+		{name: "failing latest run", args: []string{"--no-cache", "--fail-on-error", "--json", "cli"}, want: 1, wantStderr: "cli/a"},
+		{name: "success rate threshold", args: []string{"--no-cache", "--fail-threshold", "90", "--json", "cli"}, want: 1},
+		{name: "unknown owner", args: []string{"--no-cache", "nobody"}, want: 1, wantStderr: "could not find a user or org called 'nobody'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			warnings.flush(io.Discard)
+
+			f := newFakeFetcher()
+			f.addRepo("cli/a", false)
+			f.addWorkflow("cli/a", "CI",
+				completedRun("failure", time.Hour, time.Minute),
+				completedRun("success", 2*time.Hour, time.Minute))
+
+			var stdout, stderr bytes.Buffer
+			got := runCLI(tt.args, &stdout, &stderr, func(string) (Fetcher, error) { return f, nil })
+			if got != tt.want {
+				t.Errorf("got exit code %d, want %d; stderr: %s", got, tt.want, stderr.String())
+			}
+			if !strings.Contains(stdout.String(), tt.wantStdout) {
+				t.Errorf("stdout %q doesn't contain %q", stdout.String(), tt.wantStdout)
+			}
+			if !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("stderr %q doesn't contain %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestFailOnErrorExitCode(t *testing.T) {
+	healthy := func(f *fakeFetcher) {
+		f.addWorkflow("cli/a", "CI",
+			completedRun("success", time.Hour, time.Minute),
+			completedRun("failure", 2*time.Hour, time.Minute))
+	}
+	failing := func(f *fakeFetcher) {
+		f.addWorkflow("cli/a", "CI",
+			completedRun("failure", time.Hour, time.Minute),
+			completedRun("success", 2*time.Hour, time.Minute))
+	}
+	// Release is failing but has a single run, so --min-runs 2 hides it.
+	failingOnce := func(f *fakeFetcher) {
+		healthy(f)
+		f.addWorkflow("cli/a", "Release", completedRun("failure", 30*time.Minute, time.Minute))
+	}
+	// Flaky's latest run failed, but CI fails more often, so --top 1 only
+	// shows CI.
+	flaky := func(f *fakeFetcher) {
+		f.addWorkflow("cli/a", "CI",
+			completedRun("success", time.Hour, time.Minute),
+			completedRun("failure", 2*time.Hour, time.Minute),
+			completedRun("failure", 3*time.Hour, time.Minute))
+		f.addWorkflow("cli/a", "Flaky",
+			completedRun("failure", time.Hour, time.Minute),
+			completedRun("success", 2*time.Hour, time.Minute),
+			completedRun("success", 3*time.Hour, time.Minute),
+			completedRun("success", 4*time.Hour, time.Minute))
+	}
+
+	tests := []struct {
+		name       string
+		workflows  func(*fakeFetcher)
+		args       []string
+		want       int
+		wantStatus string
+	}{
+		{name: "healthy", workflows: healthy, want: 0, wantStatus: `"exit_code":0`},
+		{name: "failing", workflows: failing, want: 1, wantStatus: `"reasons":["failing"]`},
+		{name: "failure hidden by --min-runs", workflows: failingOnce, args: []string{"--min-runs", "2"}, want: 0, wantStatus: `"workflows":1`},
+		{name: "failure shown without --min-runs", workflows: failingOnce, want: 1, wantStatus: `"workflows":2`},
+		{name: "failure hidden by --top", workflows: flaky, args: []string{"--top", "1"}, want: 0, wantStatus: `"workflows":1`},
+		{name: "failure shown by a larger --top", workflows: flaky, args: []string{"--top", "2"}, want: 1, wantStatus: `"workflows":2`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			warnings.flush(io.Discard)
+
+			f := newFakeFetcher()
+			f.addRepo("cli/a", false)
+			tt.workflows(f)
+
+			args := append([]string{"--no-cache", "--json", "--fail-on-error", "--status-json", "-"}, tt.args...)
+			var stdout, stderr bytes.Buffer
+			got := runCLI(append(args, "cli"), &stdout, &stderr, func(string) (Fetcher, error) { return f, nil })
+			if got != tt.want {
+				t.Errorf("got exit code %d, want %d; stderr: %s", got, tt.want, stderr.String())
+			}
+			if !strings.Contains(stderr.String(), tt.wantStatus) {
+				t.Errorf("status %q doesn't contain %s", stderr.String(), tt.wantStatus)
+			}
+			if tt.want == 0 && strings.Contains(stderr.String(), "failing:") {
+				t.Errorf("reported failing workflows: %s", stderr.String())
+			}
+		})
+	}
+}
+
+// billable is a timing payload of ubuntu and macOS milliseconds.
+func billable(ubuntuMs, macOsMs int) billablePayload {
+	var bp billablePayload
+	bp.Ubuntu.TotalMs = ubuntuMs
+	bp.MacOs.TotalMs = macOsMs
+	return bp
+}
+
+func TestBillableIsPerWorkflow(t *testing.T) {
+	f := newFakeFetcher()
+	f.addRepo("cli/private", true)
+	ci := f.addWorkflow("cli/private", "CI",
+		completedRun("success", time.Hour, time.Minute),
+		completedRun("success", 2*time.Hour, time.Minute))
+	release := f.addWorkflow("cli/private", "Release",
+		completedRun("success", time.Hour, time.Minute))
+	f.timings[f.runs[ci.URL][0].URL] = billable(1000, 0)
+	f.timings[f.runs[ci.URL][1].URL] = billable(2000, 500)
+	f.timings[f.runs[release.URL][0].URL] = billable(0, 60000)
+
+	opts := testOptions(t, "--sort", "none", "cli")
+	repos, err := fetchDashboard(context.Background(), f, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		workflow        string
+		total, ubuntu   int
+		macOS, firstRun int
+	}{
+		{workflow: "CI", total: 3500, ubuntu: 3000, macOS: 500, firstRun: 1000},
+		{workflow: "Release", total: 60000, ubuntu: 0, macOS: 60000, firstRun: 60000},
+	}
+	for i, tt := range tests {
+		w := repos[0].Workflows[i]
+		if w.Name != tt.workflow {
+			t.Fatalf("got workflow %s at %d, want %s", w.Name, i, tt.workflow)
+		}
+		if w.BillableMs != tt.total || w.BillableUbuntuMs != tt.ubuntu || w.BillableMacOsMs != tt.macOS {
+			t.Errorf("%s: got %d ms (ubuntu %d, macOS %d), want %d (ubuntu %d, macOS %d)",
+				w.Name, w.BillableMs, w.BillableUbuntuMs, w.BillableMacOsMs, tt.total, tt.ubuntu, tt.macOS)
+		}
+		if w.Runs[0].BillableMs != tt.firstRun {
+			t.Errorf("%s: got %d ms for the latest run, want %d", w.Name, w.Runs[0].BillableMs, tt.firstRun)
+		}
+	}
+	if got := repos[0].TotalBillableMs(); got != 63500 {
+		t.Errorf("got %d ms for the repository, want 63500", got)
+	}
+}
+
+func TestFetchDashboardWorkflowFilter(t *testing.T) {
+	f := newFakeFetcher()
+	f.addRepo("cli/a", false)
+	for _, name := range []string{"CI", "deploy-staging", "deploy-prod", "pre-deploy-check"} {
+		f.addWorkflow("cli/a", name, completedRun("success", time.Hour, time.Minute))
+	}
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{want: "cli/a:CI cli/a:deploy-staging cli/a:deploy-prod cli/a:pre-deploy-check"},
+		{args: []string{"-w", "deploy-*"}, want: "cli/a:deploy-staging cli/a:deploy-prod"},
+		{args: []string{"-w", "deploy"}, want: "cli/a:deploy-staging cli/a:deploy-prod cli/a:pre-deploy-check"},
+		{args: []string{"-w", "deploy-*", "-w", "ci"}, want: "cli/a:CI cli/a:deploy-staging cli/a:deploy-prod"},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			opts := testOptions(t, append(tt.args, "cli")...)
+			repos, err := fetchDashboardEach(context.Background(), f, opts, func(*repositoryData) {})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := workflowNames(repos); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchDashboardWorkflowIDAndFile(t *testing.T) {
+	f := newFakeFetcher()
+	f.addRepo("cli/a", false)
+	f.addWorkflow("cli/a", "CI", completedRun("success", time.Hour, time.Minute))
+	// Two workflows called Deploy, told apart by id and file.
+	f.addWorkflow("cli/a", "Deploy", completedRun("success", time.Hour, time.Minute))
+	second := f.addWorkflow("cli/a", "Deploy", completedRun("failure", time.Hour, time.Minute))
+	second.Path = ".github/workflows/deploy-eu.yml"
+	f.workflows["cli/a"][2] = second
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"--workflow-id", "3"}, want: "failure"},
+		{args: []string{"--workflow-file", "deploy-eu.yml"}, want: "failure"},
+		{args: []string{"--workflow-file", ".github/workflows/deploy.yml"}, want: "success"},
+		{args: []string{"--workflow-id", "2", "--workflow-id", "3"}, want: "success failure"},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			opts := testOptions(t, append(tt.args, "cli")...)
+			repos, err := fetchDashboardEach(context.Background(), f, opts, func(*repositoryData) {})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := []string{}
+			for _, rw := range allWorkflows(repos) {
+				got = append(got, rw.Workflow.Runs[0].Conclusion)
+			}
+			if strings.Join(got, " ") != tt.want {
+				t.Errorf("got %s (%s), want %s", strings.Join(got, " "), workflowNames(repos), tt.want)
+			}
+		})
+	}
+}
+
+func TestPopulateReposExcluded(t *testing.T) {
+	f := newFakeFetcher()
+	f.addRepo("cli/cli", false)
+	f.addRepo("cli/sandbox-a", false)
+	f.addRepo("other/sandbox-b", false)
+
+	opts := testOptions(t, "--exclude-repos", "cli/sandbox-*", "cli", "other")
+	repos, err := populateRepos(context.Background(), f, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := strings.Join(repoNames(repos), " "), "cli/cli other/sandbox-b"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestPopulateReposVisibility(t *testing.T) {
+	f := newFakeFetcher()
+	f.addRepo("cli/cli", false)
+	f.addRepo("cli/internal", true)
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{want: "cli/cli cli/internal"},
+		{args: []string{"--private-only"}, want: "cli/internal"},
+		{args: []string{"--public-only"}, want: "cli/cli"},
+		{args: []string{"--private-only", "-r", "cli,internal"}, want: "cli/internal"},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			opts := testOptions(t, append(tt.args, "cli")...)
+			repos, err := populateRepos(context.Background(), f, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(repoNames(repos), " "); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPopulateReposFileOwners(t *testing.T) {
+	f := newFakeFetcher()
+	f.addRepo("cli/cli", false)
+	f.addRepo("other/go-gh", false)
+
+	path := filepath.Join(t.TempDir(), "repos.txt")
+	if err := os.WriteFile(path, []byte("cli\nother/go-gh\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Bare names belong to the first selector; owners in the file win.
+	opts := testOptions(t, "--repos-file", path, "cli")
+	repos, err := populateRepos(context.Background(), f, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := strings.Join(repoNames(repos), " "), "cli/cli other/go-gh"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if f.called("repo:cli/go-gh") > 0 {
+		t.Errorf("looked the file's other/go-gh up under the selector: %v", f.calls)
+	}
+}
+
+func TestSinceBoundary(t *testing.T) {
+	since := time.Date(2024, 5, 6, 12, 0, 0, 0, time.UTC)
+	finishedAt := func(conclusion string, at time.Time) runPayload {
+		return runPayload{Status: "completed", Conclusion: conclusion, CreatedAt: at.Add(-time.Minute), UpdatedAt: at}
+	}
+
+	f := newFakeFetcher()
+	f.addRepo("cli/a", false)
+	f.addWorkflow("cli/a", "CI",
+		finishedAt("success", since.Add(time.Second)),
+		finishedAt("cancelled", since),
+		finishedAt("failure", since.Add(-time.Second)))
+
+	opts := testOptions(t, "--since", since.Format(time.RFC3339), "cli")
+	repos, err := fetchDashboardEach(context.Background(), f, opts, func(*repositoryData) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runs := repos[0].Workflows[0].Runs
+	if len(runs) != 1 || runs[0].Conclusion != "success" {
+		t.Errorf("got %+v, want only the run finished after --since", runs)
+	}
+}
+
+func TestGetRunTimings(t *testing.T) {
+	f := newFakeFetcher()
+	runs := []run{}
+	for i := 0; i < 20; i++ {
+		r := run{URL: fmt.Sprintf("https://api.github.com/repos/cli/private/actions/runs/%d", i)}
+		f.timings[r.URL] = billable(i*1000, i)
+		runs = append(runs, r)
+	}
+
+	timings, err := getRunTimings(context.Background(), f, runs, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(timings) != len(runs) {
+		t.Fatalf("got %d timings for %d runs", len(timings), len(runs))
+	}
+	for i, bp := range timings {
+		if bp.Ubuntu.TotalMs != i*1000 || bp.MacOs.TotalMs != i {
+			t.Errorf("run %d: got %+v, out of order", i, bp)
+		}
+	}
+	if got := len(f.calls); got != len(runs) {
+		t.Errorf("got %d calls, want one per run", got)
+	}
+
+	f.errs["timing:"+runs[7].URL] = errors.New("boom")
+	if _, err := getRunTimings(context.Background(), f, runs, 4); err == nil || !strings.Contains(err.Error(), "could not fetch run timing: boom") {
+		t.Errorf("got %v, want the failed run's error", err)
+	}
+}
+
+func TestNoBillable(t *testing.T) {
+	newFetcher := func() *fakeFetcher {
+		f := newFakeFetcher()
+		f.addRepo("cli/private", true)
+		f.addRepo("cli/public", false)
+		for _, repo := range []string{"cli/private", "cli/public"} {
+			w := f.addWorkflow(repo, "CI",
+				completedRun("success", time.Hour, time.Minute),
+				completedRun("success", 2*time.Hour, time.Minute),
+				completedRun("failure", 3*time.Hour, time.Minute))
+			for _, r := range f.runs[w.URL] {
+				f.timings[r.URL] = billable(1000, 500)
+			}
+		}
+		return f
+	}
+	timingCalls := func(f *fakeFetcher) []string {
+		calls := []string{}
+		for _, c := range f.calls {
+			if strings.HasPrefix(c, "timing:") {
+				calls = append(calls, c)
+			}
+		}
+		return calls
+	}
+
+	f := newFetcher()
+	repos, err := fetchDashboard(context.Background(), f, testOptions(t, "-c", "3", "cli"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := repos[0].Workflows[0].BillableMs; got != 4500 {
+		t.Errorf("got %d ms for the private workflow, want 4500 summed over its runs", got)
+	}
+	if got := repos[1].Workflows[0].BillableMs; got != 0 {
+		t.Errorf("got %d ms for the public workflow", got)
+	}
+	for _, c := range timingCalls(f) {
+		if strings.Contains(c, "cli/public") {
+			t.Errorf("fetched timing for a public repository: %s", c)
+		}
+	}
+
+	f = newFetcher()
+	repos, err = fetchDashboard(context.Background(), f, testOptions(t, "--no-billable", "cli"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls := timingCalls(f); len(calls) > 0 {
+		t.Errorf("fetched timings with --no-billable: %v", calls)
+	}
+	if got := repos[0].TotalBillableMs(); got != 0 {
+		t.Errorf("got %d ms with --no-billable", got)
+	}
+}
+
+func TestBillableOS(t *testing.T) {
+	var bp billablePayload
+	bp.MacOs.TotalMs, bp.Windows.TotalMs, bp.Ubuntu.TotalMs = 1, 20, 300
+
+	tests := []struct {
+		os                     string
+		macOS, windows, ubuntu int
+		wantWorkflowMs         int
+	}{
+		{os: billableOSAll, macOS: 1, windows: 20, ubuntu: 300, wantWorkflowMs: 642},
+		{os: billableOSMacOS, macOS: 1, wantWorkflowMs: 2},
+		{os: billableOSWindows, windows: 20, wantWorkflowMs: 40},
+		{os: billableOSUbuntu, ubuntu: 300, wantWorkflowMs: 600},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.os, func(t *testing.T) {
+			got := bp.only(tt.os)
+			if got.MacOs.TotalMs != tt.macOS || got.Windows.TotalMs != tt.windows || got.Ubuntu.TotalMs != tt.ubuntu {
+				t.Errorf("got %+v", got)
+			}
+
+			f := newFakeFetcher()
+			f.addRepo("cli/private", true)
+			w := f.addWorkflow("cli/private", "CI",
+				completedRun("success", time.Hour, time.Minute),
+				completedRun("success", 2*time.Hour, time.Minute))
+			for _, r := range f.runs[w.URL] {
+				f.timings[r.URL] = bp
+			}
+
+			repos, err := fetchDashboard(context.Background(), f, testOptions(t, "--billable-os", tt.os, "cli"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			wf := repos[0].Workflows[0]
+			if wf.BillableMs != tt.wantWorkflowMs {
+				t.Errorf("got %d ms for the workflow, want %d", wf.BillableMs, tt.wantWorkflowMs)
+			}
+			if wf.BillableMacOsMs != 2*tt.macOS || wf.BillableWindowsMs != 2*tt.windows || wf.BillableUbuntuMs != 2*tt.ubuntu {
+				t.Errorf("got macOS %d, Windows %d, Ubuntu %d ms", wf.BillableMacOsMs, wf.BillableWindowsMs, wf.BillableUbuntuMs)
+			}
+		})
+	}
+}
+
+func TestQueueTimeFromStartedAt(t *testing.T) {
+	created := time.Now().Add(-time.Hour)
+
+	f := newFakeFetcher()
+	f.addRepo("cli/a", false)
+	f.addWorkflow("cli/a", "CI",
+		runPayload{Status: "completed", Conclusion: "success", CreatedAt: created, RunStartedAt: created.Add(45 * time.Second), UpdatedAt: created.Add(2 * time.Minute)},
+		// Older API responses have no run_started_at.
+		runPayload{Status: "completed", Conclusion: "success", CreatedAt: created, UpdatedAt: created.Add(2 * time.Minute)})
+
+	repos, err := fetchDashboard(context.Background(), f, testOptions(t, "--show-queue", "cli"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runs := repos[0].Workflows[0].Runs
+	if !runs[0].QueueKnown || runs[0].Queued != 45*time.Second || runs[0].Elapsed != 75*time.Second {
+		t.Errorf("got queued %s (known %t), elapsed %s; want 45s queued and 1m15s elapsed", runs[0].Queued, runs[0].QueueKnown, runs[0].Elapsed)
+	}
+	if runs[1].QueueKnown || runs[1].Elapsed != 2*time.Minute {
+		t.Errorf("got queued %s (known %t), elapsed %s without a start time", runs[1].Queued, runs[1].QueueKnown, runs[1].Elapsed)
+	}
+	if got := repos[0].Workflows[0].AverageQueueTime(); got != 45*time.Second {
+		t.Errorf("got average %s, want 45s", got)
+	}
+}
+
+func TestIncludeDisabled(t *testing.T) {
+	f := newFakeFetcher()
+	f.addRepo("cli/a", false)
+	f.addWorkflow("cli/a", "CI", completedRun("success", time.Hour, time.Minute))
+	for i, state := range []string{"disabled_manually", "disabled_inactivity"} {
+		w := f.addWorkflow("cli/a", state, completedRun("failure", time.Hour, time.Minute))
+		w.State = state
+		f.workflows["cli/a"][i+1] = w
+	}
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{want: "cli/a:CI"},
+		{args: []string{"--include-disabled"}, want: "cli/a:CI cli/a:disabled_manually cli/a:disabled_inactivity"},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			f.calls = nil
+			repos, err := fetchDashboardEach(context.Background(), f, testOptions(t, append(tt.args, "cli")...), func(*repositoryData) {})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := workflowNames(repos); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+
+			for _, rw := range allWorkflows(repos) {
+				w := rw.Workflow
+				if disabled := strings.HasPrefix(w.Name, "disabled"); w.Disabled != disabled {
+					t.Errorf("%s: got disabled %t", w.Name, w.Disabled)
+				}
+				if w.Disabled && len(w.Runs) > 0 {
+					t.Errorf("%s: got %d runs for a disabled workflow", w.Name, len(w.Runs))
+				}
+			}
+			for _, w := range f.workflows["cli/a"][1:] {
+				if f.called("runs:"+w.URL) > 0 {
+					t.Errorf("fetched runs for %s", w.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestIncludeRunning(t *testing.T) {
+	f := newFakeFetcher()
+	f.addRepo("cli/a", false)
+	f.addWorkflow("cli/a", "CI",
+		runPayload{Status: "queued", CreatedAt: time.Now().Add(-time.Minute)},
+		runPayload{Status: "in_progress", CreatedAt: time.Now().Add(-5 * time.Minute)},
+		completedRun("success", time.Hour, time.Minute),
+		completedRun("failure", 2*time.Hour, time.Minute))
+
+	tests := []struct {
+		args        []string
+		wantRuns    int
+		wantRunning int
+		wantHealth  string
+	}{
+		{wantRuns: 2, wantRunning: 2, wantHealth: "✓x"},
+		{args: []string{"--include-running"}, wantRuns: 4, wantRunning: 2, wantHealth: "--✓x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			withColor(t, false)
+			repos, err := fetchDashboard(context.Background(), f, testOptions(t, append(tt.args, "cli")...))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			w := repos[0].Workflows[0]
+			if len(w.Runs) != tt.wantRuns || len(w.InProgress) != tt.wantRunning {
+				t.Errorf("got %d runs and %d in progress, want %d and %d", len(w.Runs), len(w.InProgress), tt.wantRuns, tt.wantRunning)
+			}
+			if got := w.RenderHealth(renderOptions{}); got != tt.wantHealth {
+				t.Errorf("got health %q, want %q", got, tt.wantHealth)
+			}
+			if running := w.InProgress[1]; running.Elapsed < 5*time.Minute || running.Elapsed > 6*time.Minute {
+				t.Errorf("got %s elapsed for a run created 5m ago", running.Elapsed)
+			}
+			if _, total, _ := w.SuccessRate(); total != 2 {
+				t.Errorf("got %d runs in the success rate, want only the 2 completed", total)
+			}
+		})
+	}
+}
+
+func TestRunsQuery(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{want: ""},
+		{args: []string{"--actor", "monalisa"}, want: "actor=monalisa"},
+		{args: []string{"--actor", "monalisa", "--branch", "trunk"}, want: "actor=monalisa&branch=trunk"},
+		{args: []string{"--actor", "dependabot[bot]"}, want: "actor=dependabot%5Bbot%5D"},
+		{args: []string{"--event", "schedule"}, want: "event=schedule"},
+		{args: []string{"--event", "pull_request"}, want: "event=pull_request"},
+		{args: []string{"--event", "pull_request", "--actor", "monalisa", "--branch", "trunk"}, want: "actor=monalisa&branch=trunk&event=pull_request"},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			opts := testOptions(t, append(tt.args, "cli")...)
+			if got := runsQuery(opts); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+
+			f := newFakeFetcher()
+			f.addRepo("cli/a", false)
+			w := f.addWorkflow("cli/a", "CI", completedRun("success", time.Hour, time.Minute))
+			if _, err := fetchDashboard(context.Background(), f, opts); err != nil {
+				t.Fatal(err)
+			}
+			if got := f.queries[w.URL]; got != tt.want {
+				t.Errorf("runs fetched with query %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// glyphSet is the character shown for each kind of run in health strips.
+type glyphSet struct {
+	Success string
+	Neutral string
+	Failed  string
+}
+
+var defaultGlyphs = glyphSet{
+	Success: "✓",
+	Neutral: "-",
+	Failed:  "x",
+}
+
+// glyphs is the set in use; it is set from options before rendering.
+var glyphs = defaultGlyphs
+
+const (
+	outcomeSuccess = "success"
+	outcomeNeutral = "neutral"
+	outcomeFailed  = "failed"
+)
+
+// runOutcome classifies a run as success, neutral (skipped, cancelled,
+// neutral or unfinished) or failed.
+func runOutcome(r run) string {
+	if r.Status != "completed" {
+		return outcomeNeutral
+	}
+
+	switch r.Conclusion {
+	case "success":
+		return outcomeSuccess
+	case "skipped", "cancelled", "neutral":
+		return outcomeNeutral
+	default:
+		return outcomeFailed
+	}
+}
+
+// renderLegend explains the health strip glyphs in their colors.
+func renderLegend() string {
+	labelStyle := lipgloss.NewStyle().Foreground(colors.Label)
+
+	return fmt.Sprintf("%s %s success  %s skipped, cancelled or running  %s failed",
+		labelStyle.Render("Legend:"),
+		lipgloss.NewStyle().Foreground(colors.Success).Render(glyphs.Success),
+		lipgloss.NewStyle().Foreground(colors.Neutral).Render(glyphs.Neutral),
+		lipgloss.NewStyle().Foreground(colors.Failed).Render(glyphs.Failed))
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunOutcome(t *testing.T) {
+	tests := []struct {
+		status, conclusion string
+		want               string
+	}{
+		{status: "completed", conclusion: "success", want: outcomeSuccess},
+		{status: "completed", conclusion: "failure", want: outcomeFailed},
+		{status: "completed", conclusion: "timed_out", want: outcomeFailed},
+		{status: "completed", conclusion: "startup_failure", want: outcomeFailed},
+		{status: "completed", conclusion: "action_required", want: outcomeFailed},
+		{status: "completed", conclusion: "skipped", want: outcomeNeutral},
+		{status: "completed", conclusion: "cancelled", want: outcomeNeutral},
+		{status: "completed", conclusion: "neutral", want: outcomeNeutral},
+		{status: "in_progress", want: outcomeNeutral},
+		{status: "queued", want: outcomeNeutral},
+	}
+
+	for _, tt := range tests {
+		r := run{Status: tt.status, Conclusion: tt.conclusion}
+		if got := runOutcome(r); got != tt.want {
+			t.Errorf("%s/%s: got %s, want %s", tt.status, tt.conclusion, got, tt.want)
+		}
+		if got := r.failed(); got != (tt.want == outcomeFailed) {
+			t.Errorf("%s/%s: failed() = %t, disagreeing with outcome %s", tt.status, tt.conclusion, got, tt.want)
+		}
+	}
+}
+
+func TestRenderLegend(t *testing.T) {
+	withColor(t, false)
+	old := glyphs
+	defer func() { glyphs = old }()
+
+	tests := []struct {
+		name   string
+		glyphs glyphSet
+		want   string
+	}{
+		{name: "default", glyphs: defaultGlyphs, want: "Legend: ✓ success  - skipped, cancelled or running  x failed"},
+		{name: "overridden", glyphs: glyphSet{Success: "●", Neutral: "○", Failed: "✗"}, want: "Legend: ● success  ○ skipped, cancelled or running  ✗ failed"},
+	}
+
+	for _, tt := range tests {
+		glyphs = tt.glyphs
+		if got := renderLegend(); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGlyphFlags(t *testing.T) {
+	withColor(t, false)
+	old := glyphs
+	t.Cleanup(func() { glyphs = old })
+	isolate(t)
+	warnings.flush(io.Discard)
+
+	f := newFakeFetcher()
+	f.addRepo("cli/a", false)
+	f.addWorkflow("cli/a", "CI",
+		completedRun("failure", time.Hour, time.Minute),
+		completedRun("success", 2*time.Hour, time.Minute),
+		completedRun("cancelled", 3*time.Hour, time.Minute))
+
+	args := []string{"--no-cache", "--glyph-success", "o", "--glyph-failure", "X", "--glyph-neutral", "_", "cli"}
+	var stdout, stderr bytes.Buffer
+	if code := runCLI(args, &stdout, &stderr, func(string) (Fetcher, error) { return f, nil }); code != 0 {
+		t.Fatalf("got exit code %d: %s", code, stderr.String())
+	}
+
+	for _, want := range []string{"Legend: o success  _ skipped, cancelled or running  X failed", "Health: Xo_"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("output doesn't contain %q:\n%s", want, stdout.String())
+		}
+	}
+}
@@ -1,10 +1,27 @@
 module github.com/vilmibm/actions-dashboard
 
-go 1.15
+go 1.19
 
 require (
-	github.com/charmbracelet/lipgloss v0.4.0 // indirect
-	github.com/cli/safeexec v1.0.0
-	github.com/spf13/pflag v1.0.5 // indirect
-	golang.org/x/term v0.0.0-20210927222741-03fcf44c2211 // indirect
+	github.com/charmbracelet/lipgloss v0.4.0
+	github.com/cli/go-gh v1.2.1
+	github.com/mattn/go-runewidth v0.0.13
+	github.com/muesli/termenv v0.12.0
+	github.com/spf13/pflag v1.0.5
+	golang.org/x/term v0.5.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/cli/safeexec v1.0.0 // indirect
+	github.com/cli/shurcooL-graphql v0.0.2 // indirect
+	github.com/henvic/httpretty v0.0.6 // indirect
+	github.com/kr/text v0.2.0 // indirect
+	github.com/lucasb-eyer/go-colorful v1.2.0 // indirect
+	github.com/mattn/go-isatty v0.0.16 // indirect
+	github.com/muesli/reflow v0.3.0 // indirect
+	github.com/rivo/uniseg v0.2.0 // indirect
+	github.com/thlib/go-timezone-local v0.0.0-20210907160436-ef149e42d28e // indirect
+	golang.org/x/net v0.7.0 // indirect
+	golang.org/x/sys v0.5.0 // indirect
 )
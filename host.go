@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/cli/go-gh/pkg/auth"
+)
+
+// apiHost is the GitHub host to query. When empty gh's default host is used.
+var apiHost = ""
+
+// resolvedHost is the host API calls go to: the --host flag, then gh's
+// default host (GH_HOST or its configuration), then github.com.
+func resolvedHost() string {
+	if apiHost != "" {
+		return apiHost
+	}
+	if h, _ := auth.DefaultHost(); h != "" {
+		return h
+	}
+	return defaultHost
+}
+
+// actionsURL links to a repository's Actions tab on the resolved host.
+func actionsURL(repo string) string {
+	return fmt.Sprintf("https://%s/%s/actions", resolvedHost(), repo)
+}
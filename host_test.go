@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func withHost(t *testing.T, host string) {
+	t.Helper()
+	old := apiHost
+	apiHost = host
+	t.Cleanup(func() { apiHost = old })
+}
+
+func TestResolvedHost(t *testing.T) {
+	tests := []struct {
+		name   string
+		flag   string
+		ghHost string
+		want   string
+	}{
+		{name: "default", want: "github.com"},
+		{name: "gh's default host", ghHost: "ghe.example.com", want: "ghe.example.com"},
+		{name: "--host", flag: "ghe.example.com", want: "ghe.example.com"},
+		{name: "--host over gh's default host", flag: "ghe.example.com", ghHost: "other.example.com", want: "ghe.example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GH_CONFIG_DIR", t.TempDir())
+			t.Setenv("GH_HOST", tt.ghHost)
+			withHost(t, tt.flag)
+
+			if got := resolvedHost(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if got, want := actionsURL("cli/cli"), "https://"+tt.want+"/cli/cli/actions"; got != want {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+// hostTransport records the host and path of each request.
+type hostTransport struct {
+	requests []string
+}
+
+func (ht *hostTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	ht.requests = append(ht.requests, r.URL.Host+r.URL.Path)
+	return (&fakeTransport{responses: map[string][]fakeResponse{
+		strings.TrimPrefix(r.URL.Path, "/"): {ok(`{"full_name": "cli/cli"}`)},
+	}}).RoundTrip(r)
+}
+
+func TestNewRESTClientHost(t *testing.T) {
+	tests := []struct {
+		host string
+		want string
+	}{
+		{host: "github.com", want: "api.github.com/repos/cli/cli"},
+		{host: "ghe.example.com", want: "ghe.example.com/api/v3/repos/cli/cli"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			t.Setenv("GH_CONFIG_DIR", t.TempDir())
+			t.Setenv("GH_TOKEN", "test")
+			t.Setenv("GH_ENTERPRISE_TOKEN", "test")
+			t.Setenv("TMPDIR", t.TempDir())
+
+			ht := &hostTransport{}
+			client, err := newRESTClient(tt.host, ht)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var repo repositoryData
+			if err := client.DoWithContext(context.Background(), http.MethodGet, "repos/cli/cli", nil, &repo); err != nil {
+				t.Fatal(err)
+			}
+			if len(ht.requests) != 1 || ht.requests[0] != tt.want {
+				t.Errorf("got requests %v, want %s", ht.requests, tt.want)
+			}
+		})
+	}
+}
+
+func TestHostFlag(t *testing.T) {
+	isolate(t)
+	withHost(t, "")
+
+	var gotHost string
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"--no-cache", "--markdown", "--host", "ghe.example.com", "cli"}, &stdout, &stderr, func(host string) (Fetcher, error) {
+		gotHost = host
+		return dashboardFixture(), nil
+	})
+	if code != 0 {
+		t.Fatalf("got exit code %d; stderr: %s", code, stderr.String())
+	}
+	if gotHost != "ghe.example.com" {
+		t.Errorf("fetcher made for host %q", gotHost)
+	}
+	if !strings.Contains(stdout.String(), "(https://ghe.example.com/cli/a/actions)") {
+		t.Errorf("links don't go to --host:\n%s", stdout.String())
+	}
+}
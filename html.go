@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"github.com/vilmibm/actions-dashboard/util"
+)
+
+// htmlPageTmpl is a complete page with its CSS inline, so the output can be
+// published anywhere as a single file. html/template escapes repository and
+// workflow names.
+var htmlPageTmpl = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>GitHub Actions dashboard</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #24292f; }
+h2 { margin-top: 2em; }
+h2 a { color: inherit; }
+.cards { display: flex; flex-wrap: wrap; gap: 1em; }
+.card { border: 3px double {{ .Colors.Border }}; padding: 1em; width: 16em; }
+.card h3 { margin: 0 0 0.5em; font-size: 1em; overflow: hidden; text-overflow: ellipsis; white-space: nowrap; }
+.card dl { display: grid; grid-template-columns: auto 1fr; gap: 0.25em 0.5em; margin: 0; }
+.card dt { color: {{ .Colors.Label }}; }
+.card dd { margin: 0; }
+.health { font-family: monospace; font-size: 1.2em; }
+.success { color: {{ .Colors.Success }}; }
+.neutral { color: {{ .Colors.Neutral }}; }
+.failed { color: {{ .Colors.Failed }}; }
+.empty { color: {{ .Colors.Label }}; }
+</style>
+</head>
+<body>
+<h1>GitHub Actions dashboard</h1>
+<p>Legend: <span class="success">{{ .Glyphs.Success }}</span> success <span class="neutral">{{ .Glyphs.Neutral }}</span> skipped, cancelled or running <span class="failed">{{ .Glyphs.Failed }}</span> failed</p>
+{{- range .Repos }}
+<h2><a href="{{ .URL }}">{{ .Name }}</a></h2>
+{{- if not .Workflows }}
+<p class="empty">No workflows</p>
+{{- else }}
+<div class="cards">
+{{- range .Workflows }}
+<div class="card">
+<h3 title="{{ .Name }}">{{ .Name }}</h3>
+<dl>
+<dt>Health</dt><dd class="health">{{ range .Health }}<span class="{{ .Outcome }}">{{ .Glyph }}</span>{{ end }}</dd>
+<dt>Success</dt><dd>{{ .Success }}</dd>
+<dt>Avg elapsed</dt><dd>{{ .AvgElapsed }}</dd>
+<dt>Billable</dt><dd>{{ .Billable }}</dd>
+</dl>
+</div>
+{{- end }}
+</div>
+{{- end }}
+{{- end }}
+</body>
+</html>
+`))
+
+// htmlPalette is the palette as CSS colors.
+type htmlPalette struct {
+	Success template.CSS
+	Neutral template.CSS
+	Failed  template.CSS
+	Border  template.CSS
+	Label   template.CSS
+}
+
+// cssColor turns a palette color, either hex or an ANSI color number, into a
+// CSS hex color.
+func cssColor(c lipgloss.Color) template.CSS {
+	return template.CSS(termenv.ConvertToRGB(termenv.TrueColor.Color(string(c))).Hex())
+}
+
+func toHTMLPalette(p palette) htmlPalette {
+	return htmlPalette{
+		Success: cssColor(p.Success),
+		Neutral: cssColor(p.Neutral),
+		Failed:  cssColor(p.Failed),
+		Border:  cssColor(p.Border),
+		Label:   cssColor(p.Label),
+	}
+}
+
+type htmlGlyph struct {
+	Outcome string
+	Glyph   string
+}
+
+type htmlWorkflow struct {
+	Name       string
+	Health     []htmlGlyph
+	Success    string
+	AvgElapsed string
+	Billable   string
+}
+
+type htmlRepository struct {
+	Name      string
+	URL       string
+	Workflows []htmlWorkflow
+}
+
+func toHTMLWorkflow(w *workflow) htmlWorkflow {
+	hw := htmlWorkflow{
+		Name:       w.Name,
+		Success:    "-",
+		AvgElapsed: w.AverageElapsed().String(),
+		Billable:   "-",
+	}
+
+	for i, r := range w.Runs {
+		if i >= w.maxRuns() {
+			break
+		}
+		hw.Health = append(hw.Health, htmlGlyph{Outcome: runOutcome(r), Glyph: runGlyph(r)})
+	}
+
+	if successes, total, pct := w.SuccessRate(); total > 0 {
+		hw.Success = fmt.Sprintf("%d/%d (%.0f%%)", successes, total, pct)
+	}
+	if w.BillableMs > 0 {
+		hw.Billable = util.PrettyMS(w.BillableMs)
+	}
+
+	return hw
+}
+
+// RenderHTML writes a self-contained HTML page with a section of workflow
+// cards per repository, for publishing to a static site. It is colored with
+// the palette in use, and repositories without workflows are skipped unless
+// showEmpty is set.
+func RenderHTML(out io.Writer, repos []*repositoryData, showEmpty bool) error {
+	data := struct {
+		Colors htmlPalette
+		Glyphs glyphSet
+		Repos  []htmlRepository
+	}{Colors: toHTMLPalette(colors), Glyphs: glyphs}
+
+	for _, r := range repos {
+		if len(r.Workflows) == 0 && !showEmpty {
+			continue
+		}
+		hr := htmlRepository{Name: r.Name, URL: actionsURL(r.Name)}
+		for _, w := range r.Workflows {
+			hr.Workflows = append(hr.Workflows, toHTMLWorkflow(w))
+		}
+		data.Repos = append(data.Repos, hr)
+	}
+
+	return htmlPageTmpl.Execute(out, data)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRenderHTMLGolden(t *testing.T) {
+	tests := []struct {
+		name      string
+		golden    string
+		palette   palette
+		showEmpty bool
+	}{
+		{name: "default", golden: "dashboard.golden.html", palette: defaultPalette},
+		{name: "light theme with empty repositories", golden: "dashboard-light.golden.html", palette: themes[themeLight], showEmpty: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old := colors
+			colors = tt.palette
+			defer func() { colors = old }()
+
+			var buf bytes.Buffer
+			if err := RenderHTML(&buf, goldenDashboard(), tt.showEmpty); err != nil {
+				t.Fatal(err)
+			}
+
+			checkGolden(t, tt.golden, buf.Bytes())
+		})
+	}
+}
+
+func TestRenderHTMLColors(t *testing.T) {
+	old := colors
+	colors = palette{Success: "#00ff00", Neutral: "8", Failed: "196", Border: "63", Label: "#123456"}
+	defer func() { colors = old }()
+
+	var buf bytes.Buffer
+	if err := RenderHTML(&buf, goldenDashboard(), false); err != nil {
+		t.Fatal(err)
+	}
+
+	// ANSI colors are written as their hex equivalents.
+	for _, want := range []string{
+		".success { color: #00ff00; }",
+		".neutral { color: #808080; }",
+		".failed { color: #ff0000; }",
+		"border: 3px double #5f5fff;",
+		".card dt { color: #123456; }",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("page doesn't contain %q", want)
+		}
+	}
+}
+
+func TestRenderHTMLShowEmpty(t *testing.T) {
+	for _, showEmpty := range []bool{false, true} {
+		var buf bytes.Buffer
+		if err := RenderHTML(&buf, goldenDashboard(), showEmpty); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := strings.Contains(buf.String(), ">cli/empty</a>"); got != showEmpty {
+			t.Errorf("with show empty %v, got cli/empty listed %v", showEmpty, got)
+		}
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestRenderHTMLWriteError(t *testing.T) {
+	err := RenderHTML(failingWriter{}, goldenDashboard(), false)
+	if err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("got %v, want the write error", err)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+type inventoryEntry struct {
+	Repo    string
+	Name    string
+	State   string
+	Path    string
+	LastRun time.Time
+}
+
+// getInventory lists every workflow in a repository that matches the
+// --workflow filters, including disabled ones, with when each last ran. Only
+// the latest run of each workflow is fetched. Repositories without Actions
+// have no workflows; running out of API calls or time returns the entries
+// fetched so far along with the error.
+func getInventory(ctx context.Context, f Fetcher, repoData repositoryData, opts *options) ([]inventoryEntry, error) {
+	workflows, err := listWorkflows(ctx, f, repoData.Name)
+	if isNotFound(err) {
+		logger.debugf("%s: Actions not enabled, skipping", repoData.Name)
+		return []inventoryEntry{}, nil
+	} else if err != nil {
+		return []inventoryEntry{}, err
+	}
+
+	matched := []workflowsPayload{}
+	for _, w := range workflows {
+		if matchesWorkflow(w.Name, opts.Workflows) && matchesWorkflowFile(w, opts.WorkflowIDs, opts.WorkflowFiles) {
+			matched = append(matched, w)
+		}
+	}
+
+	entries := make([]inventoryEntry, len(matched))
+	errs := make([]error, len(matched))
+
+	runPool(opts.MaxConcurrency, len(matched), func(i int) {
+		w := matched[i]
+		entries[i] = inventoryEntry{
+			Repo:  repoData.Name,
+			Name:  w.Name,
+			State: w.State,
+			Path:  w.Path,
+		}
+
+		var runs []runPayload
+		if runs, errs[i] = f.Runs(ctx, w, "", 1); errs[i] == nil && len(runs) > 0 {
+			entries[i].LastRun = runs[0].CreatedAt
+		}
+	})
+
+	var cutShortErr error
+	out := []inventoryEntry{}
+	for i, err := range errs {
+		if isCutShort(err) {
+			cutShortErr = err
+		} else if err != nil {
+			return nil, err
+		} else {
+			out = append(out, entries[i])
+		}
+	}
+
+	return out, cutShortErr
+}
+
+// renderInventory prints every workflow across the selected repositories
+// along with its state, path and when it last ran.
+func renderInventory(out io.Writer, f Fetcher, opts *options) error {
+	ctx, cancel := opts.fetchContext(context.Background())
+	defer cancel()
+
+	apiBudget.reset()
+	repos, err := populateRepos(ctx, f, opts)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %s (--timeout) fetching repository data", opts.Timeout)
+	} else if err != nil {
+		return fmt.Errorf("could not fetch repository data: %w", err)
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "REPO\tWORKFLOW\tSTATE\tPATH\tLAST RUN")
+
+	for _, r := range repos {
+		entries, err := getInventory(ctx, f, *r, opts)
+		if isCutShort(err) {
+			// List what was fetched before the budget or time ran out.
+			warnCutShort(opts, err)
+		} else if err != nil {
+			return err
+		}
+
+		for _, e := range entries {
+			lastRun := "-"
+			if !e.LastRun.IsZero() {
+				lastRun = e.LastRun.Local().Format("2006-01-02")
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", e.Repo, e.Name, e.State, e.Path, lastRun)
+		}
+
+		if isCutShort(err) {
+			break
+		}
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// The json* types define the --format json schema. They are kept separate
+// from the internal types so that refactors don't change the output.
+
+// jsonDuration is a duration as nanoseconds, for programs, and as Go's
+// duration string (eg "1m30s"), for people.
+type jsonDuration struct {
+	Nanoseconds int64  `json:"ns"`
+	Human       string `json:"human"`
+}
+
+func toJSONDuration(d time.Duration) jsonDuration {
+	return jsonDuration{Nanoseconds: int64(d), Human: d.String()}
+}
+
+type jsonRun struct {
+	Status         string       `json:"status"`
+	Conclusion     string       `json:"conclusion"`
+	Event          string       `json:"event"`
+	FinishedAt     time.Time    `json:"finished_at"`
+	ElapsedSeconds float64      `json:"elapsed_seconds"`
+	Elapsed        jsonDuration `json:"elapsed"`
+	BillableMs     int          `json:"billable_ms"`
+	URL            string       `json:"url"`
+	HTMLURL        string       `json:"html_url"`
+}
+
+type jsonHealth struct {
+	Glyphs      string  `json:"glyphs"`
+	Successes   int     `json:"successes"`
+	Total       int     `json:"total"`
+	SuccessRate float64 `json:"success_rate"`
+}
+
+type jsonBillableByOS struct {
+	MacOsMs   int `json:"macos_ms"`
+	WindowsMs int `json:"windows_ms"`
+	UbuntuMs  int `json:"ubuntu_ms"`
+}
+
+type jsonWorkflow struct {
+	Name              string           `json:"name"`
+	Disabled          bool             `json:"disabled"`
+	Health            jsonHealth       `json:"health"`
+	AvgElapsedSeconds float64          `json:"avg_elapsed_seconds"`
+	AvgElapsed        jsonDuration     `json:"avg_elapsed"`
+	BillableMs        int              `json:"billable_ms"`
+	BillableByOS      jsonBillableByOS `json:"billable_by_os"`
+	Runs              []jsonRun        `json:"runs"`
+}
+
+type jsonRepository struct {
+	Name      string         `json:"name"`
+	Private   bool           `json:"private"`
+	Workflows []jsonWorkflow `json:"workflows"`
+}
+
+func toJSONWorkflow(w *workflow) jsonWorkflow {
+	successes, total, pct := w.SuccessRate()
+	jw := jsonWorkflow{
+		Name:     w.Name,
+		Disabled: w.Disabled,
+		Health: jsonHealth{
+			Successes:   successes,
+			Total:       total,
+			SuccessRate: pct,
+		},
+		AvgElapsedSeconds: w.AverageElapsed().Seconds(),
+		AvgElapsed:        toJSONDuration(w.AverageElapsed()),
+		BillableMs:        w.BillableMs,
+		BillableByOS: jsonBillableByOS{
+			MacOsMs:   w.BillableMacOsMs,
+			WindowsMs: w.BillableWindowsMs,
+			UbuntuMs:  w.BillableUbuntuMs,
+		},
+		Runs: []jsonRun{},
+	}
+
+	for i, r := range w.Runs {
+		if i < w.maxRuns() {
+			jw.Health.Glyphs += runGlyph(r)
+		}
+		jw.Runs = append(jw.Runs, jsonRun{
+			Status:         r.Status,
+			Conclusion:     r.Conclusion,
+			Event:          r.Event,
+			FinishedAt:     r.Finished,
+			ElapsedSeconds: r.Elapsed.Seconds(),
+			Elapsed:        toJSONDuration(r.Elapsed),
+			BillableMs:     r.BillableMs,
+			URL:            r.URL,
+			HTMLURL:        r.HTMLURL,
+		})
+	}
+
+	return jw
+}
+
+// EncodeDashboard writes the collected dashboard data as an indented JSON
+// array of repositories.
+func EncodeDashboard(out io.Writer, repos []*repositoryData) error {
+	payload := []jsonRepository{}
+	for _, r := range repos {
+		jr := jsonRepository{Name: r.Name, Private: r.Private, Workflows: []jsonWorkflow{}}
+		for _, w := range r.Workflows {
+			jr.Workflows = append(jr.Workflows, toJSONWorkflow(w))
+		}
+		payload = append(payload, jr)
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(payload)
+}
+
+// jsonLine is a workflow on its own, labelled with its repository, for
+// --format jsonl.
+type jsonLine struct {
+	Owner   string `json:"owner"`
+	Repo    string `json:"repo"`
+	Private bool   `json:"private"`
+	jsonWorkflow
+}
+
+// EncodeJSONLines writes each of a repository's workflows as a JSON object
+// on its own line, so that consumers can process them as they arrive.
+func EncodeJSONLines(out io.Writer, r *repositoryData) error {
+	owner, name := splitRepo("", r.Name)
+
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+	for _, w := range r.Workflows {
+		line := jsonLine{Owner: owner, Repo: name, Private: r.Private, jsonWorkflow: toJSONWorkflow(w)}
+		if err := enc.Encode(line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
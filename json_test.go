@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestEncodeDashboardGolden(t *testing.T) {
+	var buf bytes.Buffer
+	if err := EncodeDashboard(&buf, goldenDashboard()); err != nil {
+		t.Fatal(err)
+	}
+
+	checkGolden(t, "dashboard.golden.json", buf.Bytes())
+}
+
+func TestEncodeDashboardRoundTrips(t *testing.T) {
+	var buf bytes.Buffer
+	if err := EncodeDashboard(&buf, goldenDashboard()); err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded []jsonRepository
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	var again bytes.Buffer
+	enc := json.NewEncoder(&again)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), again.Bytes()) {
+		t.Errorf("decoding and encoding again changed the output:\n%s", again.String())
+	}
+}
+
+func TestEncodeJSONLines(t *testing.T) {
+	var buf bytes.Buffer
+	for _, r := range goldenDashboard() {
+		if err := EncodeJSONLines(&buf, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want one per workflow:\n%s", len(lines), buf.String())
+	}
+
+	want := []struct {
+		owner, repo, workflow string
+		private               bool
+		runs                  int
+	}{
+		{owner: "cli", repo: "cli", workflow: "CI", runs: 6},
+		{owner: "cli", repo: "cli", workflow: "Nightly"},
+		{owner: "cli", repo: "internal", workflow: `Deploy "prod", <eu>`, private: true, runs: 2},
+	}
+	for i, line := range lines {
+		if !json.Valid([]byte(line)) {
+			t.Fatalf("line %d isn't valid JSON: %s", i+1, line)
+		}
+
+		var got jsonLine
+		if err := json.Unmarshal([]byte(line), &got); err != nil {
+			t.Fatal(err)
+		}
+		w := want[i]
+		if got.Owner != w.owner || got.Repo != w.repo || got.Name != w.workflow || got.Private != w.private || len(got.Runs) != w.runs {
+			t.Errorf("line %d: got %s/%s %q private=%t with %d runs, want %s/%s %q private=%t with %d runs",
+				i+1, got.Owner, got.Repo, got.Name, got.Private, len(got.Runs), w.owner, w.repo, w.workflow, w.private, w.runs)
+		}
+	}
+
+	if !strings.Contains(lines[2], `<eu>`) {
+		t.Errorf("HTML characters were escaped: %s", lines[2])
+	}
+}
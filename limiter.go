@@ -0,0 +1,66 @@
+package main
+
+import (
+	"sync"
+)
+
+const defaultConcurrency = 8
+const defaultMinConcurrency = 1
+const defaultMaxConcurrency = 16
+
+// adaptiveLimiter bounds the number of in-flight API calls. The bound starts
+// at an initial value, is halved whenever GitHub signals rate limit pressure
+// and grows by one after a streak of successful calls, staying between min
+// and max.
+type adaptiveLimiter struct {
+	mu        sync.Mutex
+	cond      *sync.Cond
+	min       int
+	max       int
+	limit     int
+	inFlight  int
+	successes int
+}
+
+func newAdaptiveLimiter(min, initial, max int) *adaptiveLimiter {
+	l := &adaptiveLimiter{min: min, max: max, limit: initial}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+func (l *adaptiveLimiter) acquire() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for l.inFlight >= l.limit {
+		l.cond.Wait()
+	}
+	l.inFlight++
+}
+
+// release frees a slot and adjusts the limit based on how the call went.
+func (l *adaptiveLimiter) release(pressure bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inFlight--
+
+	if pressure {
+		l.successes = 0
+		l.limit /= 2
+		if l.limit < l.min {
+			l.limit = l.min
+		}
+	} else {
+		l.successes++
+		if l.successes >= l.limit && l.limit < l.max {
+			l.successes = 0
+			l.limit++
+		}
+	}
+
+	l.cond.Broadcast()
+}
+
+// apiLimiter gates every call made through apiGet.
+var apiLimiter = newAdaptiveLimiter(defaultMinConcurrency, defaultConcurrency, defaultMaxConcurrency)
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+type logLevel int
+
+const (
+	logQuiet logLevel = iota
+	logDebug
+)
+
+// leveledLogger writes diagnostics to stderr so that stdout only ever holds
+// the dashboard. Messages above the configured level are dropped.
+type leveledLogger struct {
+	mu    sync.Mutex
+	out   io.Writer
+	level logLevel
+}
+
+// logger drops everything unless --verbose points it at stderr.
+var logger = &leveledLogger{}
+
+func (l *leveledLogger) logf(level logLevel, format string, a ...interface{}) {
+	if level > l.level || l.out == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintf(l.out, "debug: "+format+"\n", a...)
+}
+
+// debugf logs details that only matter when working out why the dashboard
+// looks the way it does, eg each API call.
+func (l *leveledLogger) debugf(format string, a ...interface{}) {
+	l.logf(logDebug, format, a...)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// captureLog sends debug lines to the returned buffer until t ends.
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	old := logger
+	logger = &leveledLogger{out: &buf, level: logDebug}
+	t.Cleanup(func() { logger = old })
+	return &buf
+}
+
+func TestVerbose(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{
+			name: "verbose",
+			args: []string{"-v"},
+			want: []string{
+				"debug: dashboard cache miss: ",
+				"debug: cli/a: CI: 2 runs fetched, 2 in window, 0 in progress\n",
+				"debug: cli/b: Actions not enabled, skipping\n",
+			},
+		},
+		{name: "quiet"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			warnings.flush(io.Discard)
+			old := logger
+			t.Cleanup(func() { logger = old })
+
+			f := newFakeFetcher()
+			f.addRepo("cli/a", false)
+			f.addWorkflow("cli/a", "CI",
+				completedRun("success", time.Hour, time.Minute),
+				completedRun("success", 2*time.Hour, time.Minute))
+			f.addRepo("cli/b", false)
+			f.errs["workflows:cli/b"] = errNotFoundForTest
+
+			var stdout, stderr bytes.Buffer
+			args := append(tt.args, "--json", "cli")
+			if code := runCLI(args, &stdout, &stderr, func(string) (Fetcher, error) { return f, nil }); code != 0 {
+				t.Fatalf("got exit code %d: %s", code, stderr.String())
+			}
+
+			for _, want := range tt.want {
+				if !strings.Contains(stderr.String(), want) {
+					t.Errorf("stderr doesn't contain %q:\n%s", want, stderr.String())
+				}
+			}
+			if len(tt.want) == 0 && strings.Contains(stderr.String(), "debug:") {
+				t.Errorf("got debug lines without --verbose:\n%s", stderr.String())
+			}
+			if strings.Contains(stdout.String(), "debug:") {
+				t.Error("debug lines went to stdout")
+			}
+		})
+	}
+}
+
+func TestVerboseAPICalls(t *testing.T) {
+	ft := &fakeTransport{responses: map[string][]fakeResponse{
+		"repos/cli/cli": {{status: http.StatusBadGateway, body: `{"message": "Bad Gateway"}`}, ok(`{"full_name": "cli/cli"}`)},
+	}}
+	useFakeAPI(t, ft, 1)
+	log := captureLog(t)
+
+	if _, err := (ghFetcher{}).Repo(context.Background(), "cli", "cli"); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(log.String()), "\n")
+	want := []string{
+		"debug: GET repos/cli/cli failed after ",
+		"debug: repos/cli/cli failed (HTTP 502: Bad Gateway (https://api.github.com/repos/cli/cli)), retrying in 1ms",
+		"debug: GET repos/cli/cli 200 in ",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(want), log.String())
+	}
+	for i := range want {
+		if !strings.HasPrefix(lines[i], want[i]) {
+			t.Errorf("line %d: got %q, want it to start with %q", i, lines[i], want[i])
+		}
+	}
+}
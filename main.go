@@ -1,13 +1,18 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"math"
+	"net/url"
 	"os"
-	"os/exec"
+	"path"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"text/template"
@@ -16,71 +21,352 @@ import (
 	"golang.org/x/term"
 
 	"github.com/charmbracelet/lipgloss"
-	"github.com/cli/safeexec"
+	"github.com/mattn/go-runewidth"
 	flag "github.com/spf13/pflag"
 	"github.com/vilmibm/actions-dashboard/util"
 )
 
 const defaultMaxRuns = 5
 const defaultWorkflowNameLength = 17
-const defaultApiCacheTime = "60m"
+const defaultTerminalWidth = 80
+const defaultApiCacheTime = 60 * time.Minute
+const defaultInterval = time.Minute
+const defaultHost = "github.com"
+const defaultSlowThreshold = 10 * time.Minute
+const defaultVerySlowThreshold = 30 * time.Minute
+
+// apiCacheTime is how long cached API responses may be served. Polling modes
+// shorten it so each poll sees fresh data.
+var apiCacheTime = defaultApiCacheTime
+
+// renderOptions are the options that change what cards and health strips
+// show. Renderers get them from options.render rather than reading options
+// directly, so cards can be drawn without a whole command line.
+type renderOptions struct {
+	// Detailed adds a count of runs by conclusion to cards.
+	Detailed bool
+	// ShowQueue adds average queue time to cards.
+	ShowQueue bool
+	// ElapsedFormat is how durations are printed, one of
+	// validElapsedFormats.
+	ElapsedFormat string
+	// CompactHealth collapses streaks in health strips.
+	CompactHealth bool
+	// EmphasizeLatest sets the most recent run apart in health strips.
+	EmphasizeLatest bool
+	// Percentiles adds median and 95th percentile elapsed time to cards.
+	Percentiles bool
+	// SlowThreshold and VerySlowThreshold decide how average elapsed times
+	// are colored.
+	SlowThreshold     time.Duration
+	VerySlowThreshold time.Duration
+}
+
+const (
+	formatCards        = "cards"
+	formatEventSummary = "event-summary"
+	formatOTLP         = "otlp"
+	formatCSV          = "csv"
+	formatReport       = "report"
+	formatJSON         = "json"
+	formatTable        = "table"
+	formatMarkdown     = "markdown"
+	formatHTML         = "html"
+	formatJSONL        = "jsonl"
+	formatRuns         = "runs"
+)
+
+var validFormats = []string{formatCards, formatEventSummary, formatOTLP, formatCSV, formatReport, formatJSON, formatTable, formatMarkdown, formatHTML, formatJSONL, formatRuns}
+
+const (
+	sortName     = "name"
+	sortNone     = "none"
+	sortElapsed  = "elapsed"
+	sortHealth   = "health"
+	sortBillable = "billable"
+)
+
+var validSorts = []string{sortName, sortNone, sortElapsed, sortHealth, sortBillable}
+
+const (
+	elapsedFormatGo    = "go"
+	elapsedFormatClock = "clock"
+	elapsedFormatHuman = "human"
+)
+
+const (
+	groupByRepo     = "repo"
+	groupByWorkflow = "workflow"
+)
+
+var validGroupBys = []string{groupByRepo, groupByWorkflow}
+
+var validElapsedFormats = []string{elapsedFormatGo, elapsedFormatClock, elapsedFormatHuman}
+
+// formatElapsed prints d in format: Go's duration string (1m30s), a clock
+// (01:30, or 1:01:30 past an hour) or words (1 min 30 sec). Clock and words
+// are rounded to the second.
+func formatElapsed(d time.Duration, format string) string {
+	switch format {
+	case elapsedFormatClock:
+		d = d.Round(time.Second)
+		h, m, s := int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60
+		if h > 0 {
+			return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+		}
+		return fmt.Sprintf("%02d:%02d", m, s)
+	case elapsedFormatHuman:
+		if d < time.Second {
+			return fmt.Sprintf("%d ms", d.Milliseconds())
+		}
+		d = d.Round(time.Second)
+		parts := []string{}
+		if h := int(d.Hours()); h > 0 {
+			parts = append(parts, fmt.Sprintf("%d hr", h))
+		}
+		if m := int(d.Minutes()) % 60; m > 0 {
+			parts = append(parts, fmt.Sprintf("%d min", m))
+		}
+		if s := int(d.Seconds()) % 60; s > 0 {
+			parts = append(parts, fmt.Sprintf("%d sec", s))
+		}
+		return strings.Join(parts, " ")
+	}
+
+	return d.String()
+}
+
+func isOneOf(value string, valid []string) bool {
+	for _, v := range valid {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
 
+// run and workflow are also saved as JSON by the dashboard cache, so their
+// field names are pinned with tags.
 type run struct {
-	Finished   time.Time
-	Elapsed    time.Duration
-	Status     string
-	Conclusion string
-	URL        string
+	Number     int           `json:"number"`
+	Finished   time.Time     `json:"finished"`
+	Elapsed    time.Duration `json:"elapsed"`
+	Status     string        `json:"status"`
+	Conclusion string        `json:"conclusion"`
+	Event      string        `json:"event"`
+	URL        string        `json:"url"`
+	HTMLURL    string        `json:"html_url"`
+	BillableMs int           `json:"billable_ms"`
+	Artifacts  int           `json:"artifacts"`
+
+	// Queued is how long the run waited for a runner, when the API says
+	// when it started.
+	Queued     time.Duration `json:"queued"`
+	QueueKnown bool          `json:"queue_known"`
+}
+
+// failed reports whether a completed run concluded in something other than
+// success or a neutral outcome, as classified by runOutcome.
+func (r run) failed() bool {
+	return runOutcome(r) == outcomeFailed
 }
 
 type workflow struct {
-	Name       string
-	Runs       []run
-	BillableMs int
+	Name          string `json:"name"`
+	Runs          []run  `json:"runs"`
+	InProgress    []run  `json:"in_progress"` // queued or running right now, newest first
+	BillableMs    int    `json:"billable_ms"`
+	LatestFailure *run   `json:"latest_failure"`
+	MaxRuns       int    `json:"max_runs"` // how many recent runs health and averages cover
+	Disabled      bool   `json:"disabled"` // shown with --include-disabled; runs aren't fetched
+
+	// Billable time by runner OS; these sum to BillableMs.
+	BillableMacOsMs   int `json:"billable_macos_ms"`
+	BillableWindowsMs int `json:"billable_windows_ms"`
+	BillableUbuntuMs  int `json:"billable_ubuntu_ms"`
+}
+
+func (w *workflow) maxRuns() int {
+	if w.MaxRuns > 0 {
+		return w.MaxRuns
+	}
+	return defaultMaxRuns
+}
+
+// runGlyph summarizes a run with the glyph for its outcome, by default ✓
+// (success), - (neutral or unfinished) or x (failure).
+func runGlyph(r run) string {
+	switch runOutcome(r) {
+	case outcomeSuccess:
+		return glyphs.Success
+	case outcomeFailed:
+		return glyphs.Failed
+	default:
+		return glyphs.Neutral
+	}
+}
+
+// runStyle colors text by a run's outcome.
+func runStyle(r run) lipgloss.Style {
+	switch runOutcome(r) {
+	case outcomeSuccess:
+		return lipgloss.NewStyle().Foreground(colors.Success)
+	case outcomeFailed:
+		return lipgloss.NewStyle().Foreground(colors.Failed)
+	default:
+		return lipgloss.NewStyle().Foreground(colors.Neutral)
+	}
+}
+
+// renderRunGlyph renders a single run's glyph in its color.
+func renderRunGlyph(r run) string {
+	return runStyle(r).Render(runGlyph(r))
+}
+
+func (w *workflow) RenderHealth(ro renderOptions) string {
+	runs := w.Runs
+	if len(runs) > w.maxRuns() {
+		runs = runs[:w.maxRuns()]
+	}
+
+	var results string
+
+	if ro.EmphasizeLatest && len(runs) > 0 {
+		// The latest run is the workflow's current state; the rest is
+		// history.
+		results = runStyle(runs[0]).Bold(colorEnabled).Render("[" + runGlyph(runs[0]) + "]")
+		runs = runs[1:]
+	}
+
+	if ro.CompactHealth {
+		return results + renderCompactHealth(runs)
+	}
+
+	for _, r := range runs {
+		results += renderRunGlyph(r)
+	}
+
+	return results
 }
 
-func (w *workflow) RenderHealth() string {
-	successStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#32cd32"))
-	neutralStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#808080"))
-	failedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#dc143c"))
+// renderCompactHealth is a health strip that writes three or more
+// consecutive runs with the same outcome once with a count, eg "✓×5x".
+// Shorter streaks are written out since that is no longer.
+func renderCompactHealth(runs []run) string {
 	var results string
 
+	for i := 0; i < len(runs); {
+		j := i + 1
+		for j < len(runs) && runOutcome(runs[j]) == runOutcome(runs[i]) {
+			j++
+		}
+
+		if n := j - i; n >= 3 {
+			results += runStyle(runs[i]).Render(fmt.Sprintf("%s×%d", runGlyph(runs[i]), n))
+		} else {
+			for _, r := range runs[i:j] {
+				results += renderRunGlyph(r)
+			}
+		}
+
+		i = j
+	}
+
+	return results
+}
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// RenderDurationSparkline draws the elapsed time of the runs the health strip
+// covers, in the same order, scaled between the fastest and slowest. When
+// every run took as long, all bars are drawn at mid height.
+func (w *workflow) RenderDurationSparkline() string {
+	var min, max time.Duration
+	var elapsed []time.Duration
+
 	for i, r := range w.Runs {
-		if i > defaultMaxRuns {
+		if i >= w.maxRuns() {
 			break
 		}
+		if i == 0 || r.Elapsed < min {
+			min = r.Elapsed
+		}
+		if r.Elapsed > max {
+			max = r.Elapsed
+		}
+		elapsed = append(elapsed, r.Elapsed)
+	}
 
-		if r.Status != "completed" {
-			results += neutralStyle.Render("-")
+	spark := make([]rune, len(elapsed))
+	for i, e := range elapsed {
+		if max == min {
+			spark[i] = sparkBlocks[len(sparkBlocks)/2-1]
 			continue
 		}
+		spark[i] = sparkBlocks[int(e-min)*(len(sparkBlocks)-1)/int(max-min)]
+	}
+
+	return string(spark)
+}
+
+// AverageQueueTime averages how long the runs covered by the health strip
+// waited for a runner, leaving out runs without a known start time.
+func (w *workflow) AverageQueueTime() time.Duration {
+	var total time.Duration
+	var count int
 
-		switch r.Conclusion {
-		case "success":
-			results += successStyle.Render("✓")
-		case "skipped", "cancelled", "neutral":
-			results += neutralStyle.Render("-")
-		default:
-			results += failedStyle.Render("x")
+	for i, r := range w.Runs {
+		if i >= w.maxRuns() {
+			break
+		}
+		if !r.QueueKnown {
+			continue
 		}
+
+		total += r.Queued
+		count++
 	}
 
-	return results
+	if count == 0 {
+		return 0
+	}
+
+	return (total / time.Duration(count)).Round(time.Second)
+}
+
+// LastRun is when the most recent completed run finished, or the zero time if
+// there were none.
+func (w *workflow) LastRun() time.Time {
+	var last time.Time
+
+	for _, r := range w.Runs {
+		if r.Finished.After(last) {
+			last = r.Finished
+		}
+	}
+
+	return last
 }
 
 func (w *workflow) AverageElapsed() time.Duration {
 	var totalTime int
 	var averageTime int
+	var count int
 
 	for i, r := range w.Runs {
-		if i > defaultMaxRuns {
+		if i >= w.maxRuns() {
 			break
 		}
 
 		totalTime += int(r.Elapsed.Seconds())
+		count++
+	}
+
+	if count == 0 {
+		return 0
 	}
 
-	averageTime = totalTime / defaultMaxRuns
+	averageTime = totalTime / count
 
 	s := fmt.Sprintf("%ds", averageTime)
 	d, _ := time.ParseDuration(s)
@@ -88,59 +374,277 @@ func (w *workflow) AverageElapsed() time.Duration {
 	return d
 }
 
+// PercentileElapsed is the p-th percentile (0 to 100) of elapsed time over
+// the runs AverageElapsed covers, interpolating linearly between the two
+// nearest runs. It is rounded to the second, like AverageElapsed.
+func (w *workflow) PercentileElapsed(p float64) time.Duration {
+	durations := []time.Duration{}
+	for i, r := range w.Runs {
+		if i >= w.maxRuns() {
+			break
+		}
+		durations = append(durations, r.Elapsed)
+	}
+
+	if len(durations) == 0 {
+		return 0
+	}
+
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+
+	rank := p / 100 * float64(len(durations)-1)
+	lo := int(math.Floor(rank))
+	hi := int(math.Ceil(rank))
+	d := durations[lo] + time.Duration((rank-float64(lo))*float64(durations[hi]-durations[lo]))
+
+	return d.Round(time.Second)
+}
+
+// FailureCount is how many of the runs covered by the health strip failed.
+func (w *workflow) FailureCount() int {
+	var failures int
+
+	for i, r := range w.Runs {
+		if i >= w.maxRuns() {
+			break
+		}
+
+		if r.failed() {
+			failures++
+		}
+	}
+
+	return failures
+}
+
+// SuccessRate counts successful runs against every run that either succeeded
+// or failed; skipped, cancelled and neutral runs are left out of both.
+func (w *workflow) SuccessRate() (successes, total int, pct float64) {
+	for _, r := range w.Runs {
+		if r.Status != "completed" {
+			continue
+		}
+
+		switch {
+		case r.Conclusion == "success":
+			successes++
+			total++
+		case r.failed():
+			total++
+		}
+	}
+
+	if total > 0 {
+		pct = float64(successes) / float64(total) * 100
+	}
+
+	return
+}
+
+// ConclusionCounts counts runs by conclusion (success, failure, cancelled,
+// skipped, ...). Unfinished runs have no conclusion yet and are counted by
+// status instead, eg in_progress.
+func (w *workflow) ConclusionCounts() map[string]int {
+	counts := map[string]int{}
+	for _, r := range w.Runs {
+		if r.Conclusion == "" {
+			counts[r.Status]++
+			continue
+		}
+		counts[r.Conclusion]++
+	}
+	return counts
+}
+
+// conclusionOrder is the order ConclusionCounts are listed on cards; anything
+// else follows alphabetically.
+var conclusionOrder = []string{"success", "failure", "cancelled", "skipped"}
+
+// renderConclusionCounts lists counts as "2 success, 1 failure, 1 skipped".
+func renderConclusionCounts(counts map[string]int) string {
+	keys := []string{}
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	rank := func(k string) int {
+		for i, c := range conclusionOrder {
+			if c == k {
+				return i
+			}
+		}
+		return len(conclusionOrder)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if a, b := rank(keys[i]), rank(keys[j]); a != b {
+			return a < b
+		}
+		return keys[i] < keys[j]
+	})
+
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%d %s", counts[k], k)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func renderArtifactCount(count int) string {
+	if count == 0 {
+		return "no artifacts"
+	}
+
+	return util.Pluralize(count, "artifact")
+}
+
+// truncateWorkflowName shortens name to length terminal columns plus an
+// ellipsis. It cuts on rune boundaries and counts wide characters, such as
+// CJK, as two columns so cards stay aligned.
 func truncateWorkflowName(name string, length int) string {
-	if len(name) > length {
-		return name[:length] + "..."
+	if runewidth.StringWidth(name) > length {
+		return runewidth.Truncate(name, length, "") + "..."
 	}
 
 	return name
 }
 
-func getTerminalWidth() int {
-	width, _, err := term.GetSize(int(os.Stdout.Fd()))
+// getTerminalWidth is the one place the width everything is laid out for is
+// decided: override (--width) when set, then $COLUMNS, then the width of the
+// terminal on stdout, then defaultTerminalWidth.
+func getTerminalWidth(override int) int {
+	if override > 0 {
+		return override
+	}
 
-	if err != nil {
-		panic(err.Error())
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+
+	if width, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && width > 0 {
+		return width
 	}
 
-	return width
+	return defaultTerminalWidth
 }
 
-func (w *workflow) RenderCard() string {
-	workflowNameStyle := lipgloss.NewStyle().Bold(true)
-	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#808080"))
+func (w *workflow) RenderCard(ro renderOptions) string {
+	workflowNameStyle := lipgloss.NewStyle().Bold(colorEnabled)
+	labelStyle := lipgloss.NewStyle().Foreground(colors.Label)
 	var tmpl *template.Template
 	tmplData := struct {
 		Name       string
 		AvgElapsed time.Duration
 		Health     string
+		Durations  string
+		Successes  int
+		Total      int
+		Pct        float64
 		BillableMs int
+		MacOsMs    int
+		WindowsMs  int
+		UbuntuMs   int
+		Running    string
+		LastRunAgo string
+		InFlight   string
+		ShowQueue  bool
+		AvgQueue   string
+		P50        time.Duration
+		P95        time.Duration
+		Outcomes   string
 		PrettyMS   func(int) string
+		Elapsed    func(time.Duration) string
 		Label      func(string) string
 	}{
 		Name:       workflowNameStyle.Render(truncateWorkflowName(w.Name, defaultWorkflowNameLength)),
 		AvgElapsed: w.AverageElapsed(),
-		Health:     w.RenderHealth(),
+		Health:     w.RenderHealth(ro),
+		Durations:  w.RenderDurationSparkline(),
+		ShowQueue:  ro.ShowQueue,
+		AvgQueue:   formatElapsed(w.AverageQueueTime(), ro.ElapsedFormat),
 		BillableMs: w.BillableMs,
+		MacOsMs:    w.BillableMacOsMs,
+		WindowsMs:  w.BillableWindowsMs,
+		UbuntuMs:   w.BillableUbuntuMs,
 		PrettyMS:   util.PrettyMS,
 		Label: func(s string) string {
 			return labelStyle.Render(s)
 		},
+		Elapsed: func(d time.Duration) string {
+			return lipgloss.NewStyle().Foreground(elapsedColor(d, ro.SlowThreshold, ro.VerySlowThreshold)).Render(formatElapsed(d, ro.ElapsedFormat))
+		},
+	}
+
+	tmplData.Successes, tmplData.Total, tmplData.Pct = w.SuccessRate()
+
+	if ro.Percentiles {
+		tmplData.P50 = w.PercentileElapsed(50)
+		tmplData.P95 = w.PercentileElapsed(95)
+	}
+
+	if ro.Detailed {
+		tmplData.Outcomes = renderConclusionCounts(w.ConclusionCounts())
+	}
+
+	if len(w.InProgress) > 0 {
+		tmplData.Running = formatElapsed(w.InProgress[0].Elapsed, ro.ElapsedFormat)
+	}
+
+	if n := len(w.InProgress); n > 0 {
+		inFlightStyle := lipgloss.NewStyle().Foreground(colors.Slow)
+		tmplData.InFlight = inFlightStyle.Render(fmt.Sprintf("⟳ %d running", n))
+	}
+
+	if last := w.LastRun(); !last.IsZero() {
+		tmplData.LastRunAgo = util.ShortAgo(time.Since(last))
 	}
 
 	// Assumes that run data is time filtered already
 	// TODO add color etc in here:
-	if len(w.Runs) == 0 {
+	if w.Disabled {
+		tmpl, _ = template.New("disabledWorkflowCard").Parse(
+			`{{ .Name }}
+{{call .Label "Disabled"}}`)
+	} else if len(w.Runs) == 0 && len(w.InProgress) > 0 {
+		tmpl, _ = template.New("inProgressWorkflowCard").Parse(
+			`{{ .Name }}
+{{ .InFlight }}{{call .Label ", none completed yet"}}
+{{call .Label "In progress:"}} {{ .Running }}`)
+	} else if len(w.Runs) == 0 {
 		tmpl, _ = template.New("emptyWorkflowCard").Parse(
 			`{{ .Name }}
 {{call .Label "No runs"}}`)
 	} else {
 		tmpl, _ = template.New("workflowCard").Parse(
 			`{{ .Name }}
+{{- if .InFlight }}
+{{ .InFlight }}
+{{- end }}
 {{call .Label "Health:"}} {{ .Health }}
-{{call .Label "Avg elapsed:"}} {{ .AvgElapsed }}
+{{- if .LastRunAgo }}
+{{call .Label "Last run:"}} {{ .LastRunAgo }} ago
+{{- end }}
+{{- if .Total }}
+{{call .Label "Success:"}} {{ .Successes }}/{{ .Total }} ({{ printf "%.0f" .Pct }}%)
+{{- end }}
+{{- if .Outcomes }}
+{{call .Label "Runs:"}} {{ .Outcomes }}
+{{- end }}
+{{call .Label "Avg elapsed:"}} {{call .Elapsed .AvgElapsed }}
+{{- if .P95 }}
+{{call .Label "p50/p95:"}} {{call .Elapsed .P50 }} / {{call .Elapsed .P95 }}
+{{- end }}
+{{call .Label "Durations:"}} {{ .Durations }}
+{{- if .ShowQueue }}
+{{call .Label "Avg queue time:"}} {{ .AvgQueue }}
+{{- end }}
 {{- if .BillableMs }}
-{{call .Label "Billable time:"}} {{call .PrettyMS .BillableMs }}{{end}}`)
+{{call .Label "Billable time:"}} {{call .PrettyMS .BillableMs }}
+{{- if .UbuntuMs }}
+{{call .Label "  Ubuntu:"}} {{call .PrettyMS .UbuntuMs }}{{end}}
+{{- if .WindowsMs }}
+{{call .Label "  Windows:"}} {{call .PrettyMS .WindowsMs }}{{end}}
+{{- if .MacOsMs }}
+{{call .Label "  macOS:"}} {{call .PrettyMS .MacOsMs }}{{end}}
+{{- end}}`)
 	}
 	buf := bytes.Buffer{}
 	_ = tmpl.Execute(&buf, tmplData)
@@ -148,328 +652,1569 @@ func (w *workflow) RenderCard() string {
 }
 
 type repositoryData struct {
-	Name      string `json:"full_name"`
-	Private   bool
-	Workflows []*workflow
+	Name          string `json:"full_name"`
+	Private       bool
+	Archived      bool
+	DefaultBranch string `json:"default_branch"`
+	Workflows     []*workflow
 }
 
-type options struct {
-	Repositories []string
-	Last         time.Duration
-	Selector     string
-}
+// RenderHealth renders one glyph per workflow for the conclusion of its most
+// recent run.
+func (r *repositoryData) RenderHealth() string {
+	var results string
 
-func _main(opts *options) error {
-	selector := opts.Selector
-	last := opts.Last
+	for _, w := range r.Workflows {
+		if len(w.Runs) == 0 {
+			results += renderRunGlyph(run{})
+			continue
+		}
 
-	repos, err := populateRepos(opts)
-	if err != nil {
-		return fmt.Errorf("could not fetch repository data: %w", err)
+		results += renderRunGlyph(w.Runs[0])
 	}
 
-	columnWidth := defaultWorkflowNameLength + 5 // account for ellipsis and padding/border
-	cardsPerRow := (getTerminalWidth() / columnWidth) - 1
+	return results
+}
 
-	cardStyle := lipgloss.NewStyle().
-		Align(lipgloss.Left).
-		Padding(1).
-		Width(columnWidth).
-		BorderStyle(lipgloss.DoubleBorder()).
-		BorderForeground(lipgloss.Color("63"))
+// TotalBillableMs sums the billable time of every workflow in the
+// repository.
+func (r *repositoryData) TotalBillableMs() int {
+	total := 0
+	for _, w := range r.Workflows {
+		total += w.BillableMs
+	}
+	return total
+}
 
-	titleStyle := lipgloss.NewStyle().Bold(true).Align(lipgloss.Center).Width(getTerminalWidth())
-	subTitleStyle := lipgloss.NewStyle().Align(lipgloss.Center).Width(getTerminalWidth())
-	repoNameStyle := lipgloss.NewStyle().Bold(true)
-	repoHintStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#808080")).Italic(true)
+func (r *repositoryData) RenderCard() string {
+	repoNameStyle := lipgloss.NewStyle().Bold(colorEnabled)
+	labelStyle := lipgloss.NewStyle().Foreground(colors.Label)
 
-	totalBillableMs := 0
+	var successes, total int
+	for _, w := range r.Workflows {
+		s, t, _ := w.SuccessRate()
+		successes += s
+		total += t
+	}
 
-	for _, r := range repos {
-		workflows, err := getWorkflows(*r, last)
-		if err != nil {
-			return err
-		}
+	tmplData := struct {
+		Name       string
+		Workflows  int
+		Health     string
+		Successes  int
+		Total      int
+		Pct        float64
+		BillableMs int
+		PrettyMS   func(int) string
+		Label      func(string) string
+	}{
+		Name:       repoNameStyle.Render(truncateWorkflowName(r.Name, defaultWorkflowNameLength)),
+		Workflows:  len(r.Workflows),
+		Health:     r.RenderHealth(),
+		Successes:  successes,
+		Total:      total,
+		BillableMs: r.TotalBillableMs(),
+		PrettyMS:   util.PrettyMS,
+		Label: func(s string) string {
+			return labelStyle.Render(s)
+		},
+	}
+	if total > 0 {
+		tmplData.Pct = float64(successes) / float64(total) * 100
+	}
+
+	tmpl, _ := template.New("repoCard").Parse(
+		`{{ .Name }}
+{{call .Label "Workflows:"}} {{ .Workflows }}
+{{call .Label "Health:"}} {{ .Health }}
+{{- if .Total }}
+{{call .Label "Success:"}} {{ printf "%.0f" .Pct }}%
+{{- end }}
+{{- if .BillableMs }}
+{{call .Label "Billable time:"}} {{call .PrettyMS .BillableMs }}{{end}}`)
+	buf := bytes.Buffer{}
+	_ = tmpl.Execute(&buf, tmplData)
+	return buf.String()
+}
 
-		r.Workflows = workflows
+type options struct {
+	Repositories      []string
+	Last              time.Duration
+	Selector          string // the first of Selectors, which owns bare --repos names
+	Selectors         []string
+	Format            string
+	Stream            bool
+	Interval          time.Duration
+	Artifacts         bool
+	OTLPEndpoint      string
+	Sort              string
+	ShowVersion       bool
+	BOM               bool
+	RepoCards         bool
+	GroupBy           string
+	Colors            palette
+	Inventory         bool
+	Concurrency       int
+	MinConcurrency    int
+	MaxConcurrency    int
+	Host              string
+	Branch            string
+	DefaultBranchOnly bool
+	NoColor           bool
+	Width             int
+	Workflows         []string
+	WorkflowIDs       []int
+	WorkflowFiles     []string
+	MaxRuns           int
+	Output            string
+	Limit             int
+	Reverse           bool
+	FailOnError       bool
+	FailThreshold     float64
+	StatusJSON        string
+	CacheTTL          time.Duration
+	NoCache           bool
+	NoBillable        bool
+	BillableOS        string
+	IncludeDisabled   bool
+	IncludeArchived   bool
+	ShowEmpty         bool
+	Visibility        string
+	ExcludeRepos      []string
+	Watch             bool
+	Strict            bool
+	SlowThreshold     time.Duration
+	VerySlowThreshold time.Duration
+	Since             time.Time
+	IncludeRunning    bool
+	Verbose           bool
+	Quiet             bool
+	Glyphs            glyphSet
+	Retries           int
+	MaxAPICalls       int
+	Timeout           time.Duration
+	Actor             string
+	Event             string
+	ShowQueue         bool
+	Detailed          bool
+	Percentiles       bool
+	CompactHealth     bool
+	EmphasizeLatest   bool
+	DryRun            bool
+	ElapsedFormat     string
+	MinRuns           int
+	Top               int
+	TopPerRepo        bool
+}
 
-		for _, w := range workflows {
-			totalBillableMs += w.BillableMs
-		}
+// cutoff is the time before which runs are left out: Since when set,
+// otherwise Last ago.
+func (o *options) cutoff() time.Time {
+	if !o.Since.IsZero() {
+		return o.Since
 	}
+	return time.Now().Add(-o.Last)
+}
 
-	fmt.Println(titleStyle.Render(fmt.Sprintf("GitHub Actions dashboard for %s for the past %s", selector, util.FuzzyAgo(opts.Last))))
-	fmt.Println(subTitleStyle.Render(fmt.Sprintf("Total billable time: %s", util.PrettyMS(totalBillableMs))))
-
-	for _, r := range repos {
-		if len(r.Workflows) == 0 {
-			continue
-		}
-		fmt.Println()
-		fmt.Print(repoNameStyle.Render(r.Name))
-		// TODO leverage go-gh to determine what host to use
-		// (NB: go-gh needs a PR in order to help with this)
-		fmt.Print(repoHintStyle.Render(fmt.Sprintf(" https://github.com/%s/actions\n", r.Name)))
-		fmt.Println()
+// render picks out the options that change how cards and health strips look.
+func (o *options) render() renderOptions {
+	return renderOptions{
+		Detailed:          o.Detailed,
+		ShowQueue:         o.ShowQueue,
+		ElapsedFormat:     o.ElapsedFormat,
+		CompactHealth:     o.CompactHealth,
+		EmphasizeLatest:   o.EmphasizeLatest,
+		Percentiles:       o.Percentiles,
+		SlowThreshold:     o.SlowThreshold,
+		VerySlowThreshold: o.VerySlowThreshold,
+	}
+}
 
-		totalRows := int(math.Ceil(float64(len(r.Workflows)) / float64(cardsPerRow)))
-		cardRows := make([][]string, totalRows)
-		rowIndex := 0
+// owners names every selected organization or user for titles, eg "cli" or
+// "cli, github".
+func (o *options) owners() string {
+	return strings.Join(o.Selectors, ", ")
+}
 
-		for _, w := range r.Workflows {
-			if len(cardRows[rowIndex]) == cardsPerRow {
-				rowIndex++
-			}
+// period describes the covered window for titles, eg "for the past 30 days"
+// or "since 2024-01-01".
+func (o *options) period() string {
+	if !o.Since.IsZero() {
+		return "since " + o.Since.Format("2006-01-02")
+	}
+	return "for the past " + util.FuzzyDuration(o.Last)
+}
 
-			cardRows[rowIndex] = append(cardRows[rowIndex], cardStyle.Render(w.RenderCard()))
-		}
+// fetchDashboard collects every repository for the selector along with its
+// workflows and their runs.
+func fetchDashboard(ctx context.Context, f Fetcher, opts *options) ([]*repositoryData, error) {
+	return fetchDashboardEach(ctx, f, opts, nil)
+}
 
-		for _, row := range cardRows {
-			fmt.Println(lipgloss.JoinHorizontal(lipgloss.Top, row...))
-		}
+// fetchContext is the context for one fetch of the dashboard, derived from
+// parent and cancelled after --timeout if one was given.
+func (o *options) fetchContext(parent context.Context) (context.Context, context.CancelFunc) {
+	if o.Timeout > 0 {
+		return context.WithTimeout(parent, o.Timeout)
 	}
+	return context.WithCancel(parent)
+}
 
-	return nil
+// isCutShort reports whether err means fetching stopped early, because
+// --max-api-calls ran out or --timeout passed, so that what was fetched
+// before can still be shown.
+func isCutShort(err error) bool {
+	return errors.Is(err, errAPIBudget) || errors.Is(err, context.DeadlineExceeded)
 }
 
-func populateRepos(opts *options) ([]*repositoryData, error) {
-	result := []*repositoryData{}
-	if len(opts.Repositories) > 0 {
-		for _, repoName := range opts.Repositories {
-			repoData, err := getRepo(opts.Selector, repoName)
-			if err != nil {
-				return nil, fmt.Errorf("failed to fetch data for %s/%s: %w", opts.Selector, repoName, err)
-			}
-			result = append(result, repoData)
-		}
+// warnCutShort notes that the dashboard only covers what was fetched before
+// err, as accepted by isCutShort, stopped it.
+func warnCutShort(opts *options, err error) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		warnings.add("stopped fetching after %s (--timeout); the dashboard is incomplete", opts.Timeout)
+		return
+	}
+	warnings.add("stopped fetching after %s (--max-api-calls); the dashboard is incomplete", util.Pluralize(opts.MaxAPICalls, "API call"))
+}
 
-		return result, nil
+// fetchDashboardEach is fetchDashboard that also hands each repository to
+// each, in order, as soon as it and every repository before it are ready.
+// Progress is shown while waiting.
+func fetchDashboardEach(ctx context.Context, f Fetcher, opts *options, each func(*repositoryData)) ([]*repositoryData, error) {
+	progress.resetTimings()
+	apiBudget.reset()
+	progress.update("Fetching repositories for %s", opts.owners())
+	repos, err := populateRepos(ctx, f, opts)
+	progress.clear()
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil, fmt.Errorf("timed out after %s (--timeout) fetching repository data", opts.Timeout)
+	} else if err != nil {
+		return nil, fmt.Errorf("could not fetch repository data: %w", err)
 	}
 
-	var orgErr error
-	var userErr error
-	result, orgErr = getAllRepos(fmt.Sprintf("orgs/%s/repos", opts.Selector))
-	if orgErr != nil {
-		result, userErr = getAllRepos(fmt.Sprintf("users/%s/repos", opts.Selector))
-		if userErr != nil {
-			return nil, fmt.Errorf("could not find a user or org called '%s': %s; %s", opts.Selector, orgErr, userErr)
+	// Repositories are fetched by a pool of --max-concurrency workers but
+	// handed to each in order. On failure the rest are cancelled, and the
+	// pool is waited for so that no calls outlive this function.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make([]error, len(repos))
+	done := make([]chan struct{}, len(repos))
+	for i := range repos {
+		done[i] = make(chan struct{})
+	}
+
+	poolDone := make(chan struct{})
+	go func() {
+		defer close(poolDone)
+		runPool(opts.MaxConcurrency, len(repos), func(i int) {
+			defer close(done[i])
+			repos[i].Workflows, errs[i] = getWorkflows(ctx, f, *repos[i], opts)
+		})
+	}()
+
+	for i, r := range repos {
+		progress.update("Fetching workflows for %s (%d/%d)", r.Name, i+1, len(repos))
+		<-done[i]
+		progress.clear()
+
+		if isCutShort(errs[i]) {
+			// Show what was fetched before the budget or time ran out.
+			warnCutShort(opts, errs[i])
+		} else if errs[i] != nil {
+			cancel()
+			<-poolDone
+			return nil, errs[i]
+		}
+		if each != nil {
+			each(r)
 		}
 	}
 
-	return result, nil
+	return repos, nil
 }
 
-func getRepo(owner, name string) (*repositoryData, error) {
-	path := fmt.Sprintf("repos/%s/%s", owner, name)
-	var stdout bytes.Buffer
-	var data repositoryData
+// newGHFetcher points restClient at host and returns the Fetcher that uses
+// it.
+func newGHFetcher(host string) (Fetcher, error) {
 	var err error
-	// TODO consider using go-gh
-	if stdout, _, err = gh("api", "--cache", defaultApiCacheTime, path); err != nil {
-		return nil, err
-	}
-	if err := json.Unmarshal(stdout.Bytes(), &data); err != nil {
+	restClient, err = newRESTClient(host, nil)
+	if errors.Is(err, errNotAuthenticated) {
 		return nil, err
+	} else if err != nil {
+		return nil, fmt.Errorf("could not create API client: %w", err)
 	}
 
-	return &data, nil
+	return ghFetcher{}, nil
 }
 
-func getAllRepos(path string) ([]*repositoryData, error) {
-	// TODO consider using go-gh
-	stdout, _, err := gh("api", "--cache", defaultApiCacheTime, path)
-	if err != nil {
-		return nil, err
+// _main renders the dashboard for opts to stdout, or to --output, with
+// warnings and progress on stderr. Data comes from the Fetcher that
+// newFetcher returns for --host.
+func _main(opts *options, stdout, stderr io.Writer, newFetcher func(host string) (Fetcher, error)) error {
+	colors = opts.Colors
+	glyphs = opts.Glyphs
+	if opts.NoColor {
+		disableColor()
 	}
-
-	repoData := []*repositoryData{}
-	err = json.Unmarshal(stdout.Bytes(), &repoData)
-	if err != nil {
-		return nil, err
+	apiHost = opts.Host
+	apiRetries = opts.Retries
+	apiBudget.max = opts.MaxAPICalls
+	if opts.Verbose {
+		logger = &leveledLogger{out: stderr, level: logDebug}
 	}
+	apiLimiter = newAdaptiveLimiter(opts.MinConcurrency, opts.Concurrency, opts.MaxConcurrency)
 
-	return repoData, nil
-}
+	if opts.Stream || opts.Watch {
+		// Each poll should see fresh data, apart from workflow lists.
+		apiCacheTime = opts.Interval
+		workflowLists.ttl = workflowListTTL
+	}
 
-func getWorkflows(repoData repositoryData, last time.Duration) ([]*workflow, error) {
-	workflowsPath := fmt.Sprintf("repos/%s/actions/workflows", repoData.Name)
+	if opts.DryRun {
+		printPlan(stderr, opts)
+		return nil
+	}
 
-	// TODO consider using go-gh
-	stdout, _, err := gh("api", "--cache", defaultApiCacheTime, workflowsPath, "--jq", ".workflows")
+	fetcher, err := newFetcher(opts.Host)
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	defer warnings.flush(stderr)
+
+	out := stdout
+	if opts.Output != "" {
+		f, err := os.Create(opts.Output)
+		if err != nil {
+			return fmt.Errorf("could not open output file: %w", err)
+		}
+		defer f.Close()
+		out = f
 	}
 
-	type workflowsPayload struct {
-		Id    int `json:"id"`
-		State string
-		Name  string
-		URL   string `json:"url"`
+	if opts.Stream {
+		return streamChanges(out, stderr, fetcher, opts)
 	}
 
-	p := []workflowsPayload{}
-	err = json.Unmarshal(stdout.Bytes(), &p)
-	if err != nil {
-		return nil, err
+	if opts.Watch {
+		return watchDashboard(out, fetcher, opts)
 	}
 
-	out := []*workflow{}
+	if opts.Inventory {
+		return renderInventory(out, fetcher, opts)
+	}
 
-	type runPayload struct {
-		Id         int       `json:"id"`
-		CreatedAt  time.Time `json:"created_at"`
-		UpdatedAt  time.Time `json:"updated_at"`
-		Status     string
-		Conclusion string
-		URL        string
+	// Cards and JSON lines are printed a repository at a time as each is
+	// fetched, rather than making users of big orgs wait for everything.
+	// Picking the --top workflows across repositories has to wait, though.
+	progressive := opts.GroupBy == groupByRepo && (opts.Top == 0 || opts.TopPerRepo)
+	var cards *cardRenderer
+	if progressive && opts.Format == formatCards && !opts.RepoCards {
+		cards = newCardRenderer(out, opts)
+		cards.header()
 	}
+	streamLines := progressive && opts.Format == formatJSONL
 
-	type billablePayload struct {
-		MacOs struct {
-			TotalMs int `json:"total_ms"`
-		} `json:"MACOS"`
-		Windows struct {
-			TotalMs int `json:"total_ms"`
-		} `json:"WINDOWS"`
-		Ubuntu struct {
-			TotalMs int `json:"total_ms"`
-		} `json:"UBUNTU"`
+	if f, ok := stderr.(*os.File); ok && !opts.Verbose {
+		// Debug lines would fight with the progress line for stderr.
+		progress = newProgressLine(f)
 	}
 
-	var totalMs int
+	ctx, cancel := opts.fetchContext(context.Background())
+	defer cancel()
 
-	for _, w := range p {
-		if strings.HasPrefix(w.State, "disabled") {
-			continue
+	repos, asOf, err := cachedFetchDashboard(ctx, fetcher, opts, func(r *repositoryData) {
+		sortWorkflows(r.Workflows, opts.Sort, opts.Reverse)
+		for _, r := range shownRepos([]*repositoryData{r}, opts) {
+			if cards != nil {
+				cards.repo(r)
+			}
+			if streamLines {
+				_ = EncodeJSONLines(out, r)
+			}
 		}
+	})
+	if err != nil {
+		return err
+	}
 
-		runsPath := fmt.Sprintf("%s/runs", w.URL)
-		// TODO consider using go-gh
-		stdout, _, err = gh("api", "--cache", defaultApiCacheTime, runsPath, "--jq", ".workflow_runs")
+	shown := shownRepos(repos, opts)
+	if cards != nil {
+		cards.footer(shown, asOf)
+	} else if !streamLines {
+		err = renderDashboard(out, shown, opts, asOf)
 		if err != nil {
-			return nil, fmt.Errorf("could not call gh: %w", err)
+			return err
 		}
-		rs := []runPayload{}
-		err = json.Unmarshal(stdout.Bytes(), &rs)
-		if err != nil {
-			return nil, fmt.Errorf("could not parse json: %w", err)
+	}
+
+	problems := []string{}
+	if opts.FailOnError {
+		if err := checkFailing(shown); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if opts.FailThreshold > 0 {
+		if err := checkSuccessRates(shown, opts.FailThreshold); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if opts.StatusJSON != "" {
+		if err := writeStatusSummary(stderr, opts.StatusJSON, buildStatusSummary(shown, opts)); err != nil {
+			return err
+		}
+	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "\n"))
+	}
+
+	return nil
+}
+
+// checkSuccessRates returns an error naming every workflow whose success
+// rate over the window is below threshold percent, or nil if there are none.
+// Workflows without finished runs have no rate and always pass.
+func checkSuccessRates(repos []*repositoryData, threshold float64) error {
+	lines := []string{}
+	for _, rw := range allWorkflows(repos) {
+		if _, total, pct := rw.Workflow.SuccessRate(); total > 0 && pct < threshold {
+			lines = append(lines, fmt.Sprintf("  %s: %s (%.0f%%)", rw.Repo, rw.Workflow.Name, pct))
 		}
+	}
+	if len(lines) == 0 {
+		return nil
+	}
 
-		runs := []run{}
+	header := fmt.Sprintf("%s below %g%% success:", util.Pluralize(len(lines), "workflow"), threshold)
+	return errors.New(strings.Join(append([]string{header}, lines...), "\n"))
+}
 
-		for _, r := range rs {
-			rr := run{Status: r.Status, Conclusion: r.Conclusion, URL: r.URL}
+// shownRepos trims repos down to what gets rendered: workflows with at least
+// --min-runs runs, then the --top least healthy of those. Totals are computed
+// from the result, so they only cover rendered workflows.
+func shownRepos(repos []*repositoryData, opts *options) []*repositoryData {
+	return leastHealthy(withMinRuns(repos, opts.MinRuns), opts.Top, opts.TopPerRepo)
+}
 
-			if r.Status == "completed" {
-				rr.Finished = r.UpdatedAt
-				rr.Elapsed = r.UpdatedAt.Sub(r.CreatedAt)
-				finishedAgo := time.Since(rr.Finished)
+// checkFailing returns an error naming every workflow whose most recent run
+// failed, or nil if there are none.
+func checkFailing(repos []*repositoryData) error {
+	failing := failingWorkflows(repos)
+	if len(failing) == 0 {
+		return nil
+	}
 
-				if last-finishedAgo > 0 {
-					runs = append(runs, rr)
-				}
+	lines := []string{fmt.Sprintf("%s failing:", util.Pluralize(len(failing), "workflow"))}
+	for _, rw := range failing {
+		lines = append(lines, fmt.Sprintf("  %s: %s", rw.Repo, rw.Workflow.Name))
+	}
+
+	return errors.New(strings.Join(lines, "\n"))
+}
+
+// renderDashboard writes the collected repositories to out in the selected
+// format.
+func renderDashboard(out io.Writer, repos []*repositoryData, opts *options, asOf dataFreshness) error {
+	switch opts.Format {
+	case formatEventSummary:
+		return renderEventSummary(out, repos, opts)
+	case formatOTLP:
+		return exportOTLP(out, repos, opts)
+	case formatCSV:
+		return writeCSV(out, repos, opts.BOM)
+	case formatReport:
+		return renderReport(out, repos, opts)
+	case formatJSON:
+		return EncodeDashboard(out, repos)
+	case formatJSONL:
+		for _, r := range repos {
+			if err := EncodeJSONLines(out, r); err != nil {
+				return err
 			}
 		}
+		return nil
+	case formatTable:
+		return renderTable(out, repos, opts, asOf)
+	case formatMarkdown:
+		return renderMarkdown(out, repos, opts, asOf)
+	case formatHTML:
+		return RenderHTML(out, repos, opts.ShowEmpty)
+	case formatRuns:
+		return renderRunList(out, repos, opts)
+	}
+
+	c := newCardRenderer(out, opts)
+	c.header()
+	if opts.RepoCards {
+		c.repoGrid(repos)
+	} else if opts.GroupBy == groupByWorkflow {
+		for _, g := range pivotByWorkflow(repos) {
+			c.workflowGroup(g)
+		}
+	} else {
+		for _, r := range repos {
+			c.repo(r)
+		}
+	}
+	c.footer(repos, asOf)
+
+	return nil
+}
+
+// printCardGrid lays rendered cards out left to right, wrapping after
+// cardsPerRow cards.
+func printCardGrid(out io.Writer, cards []string, cardsPerRow int) {
+	totalRows := int(math.Ceil(float64(len(cards)) / float64(cardsPerRow)))
+	cardRows := make([][]string, totalRows)
+	rowIndex := 0
+
+	for _, c := range cards {
+		if len(cardRows[rowIndex]) == cardsPerRow {
+			rowIndex++
+		}
+
+		cardRows[rowIndex] = append(cardRows[rowIndex], c)
+	}
+
+	for _, row := range cardRows {
+		fmt.Fprintln(out, lipgloss.JoinHorizontal(lipgloss.Top, row...))
+	}
+}
+
+// sortWorkflows orders workflows by key, ascending unless reverse is set.
+// Ties, and the name key itself, are ordered by case-insensitive name.
+func sortWorkflows(workflows []*workflow, key string, reverse bool) {
+	if key == sortNone {
+		return
+	}
+
+	score := func(w *workflow) int64 {
+		switch key {
+		case sortElapsed:
+			return int64(w.AverageElapsed())
+		case sortHealth:
+			return int64(w.FailureCount())
+		case sortBillable:
+			return int64(w.BillableMs)
+		}
+		return 0
+	}
 
-		if repoData.Private {
-			for _, r := range runs {
-				runTimingPath := fmt.Sprintf("%s/timing", r.URL)
-				// TODO consider using go-gh
-				stdout, _, err = gh("api", "--cache", defaultApiCacheTime, runTimingPath, "--jq", ".billable")
-				if err != nil {
-					return nil, fmt.Errorf("could not call gh: %w", err)
+	sort.SliceStable(workflows, func(i, j int) bool {
+		a, b := score(workflows[i]), score(workflows[j])
+		if a != b {
+			return (a < b) != reverse
+		}
+		an, bn := strings.ToLower(workflows[i].Name), strings.ToLower(workflows[j].Name)
+		if key == sortName && reverse {
+			return an > bn
+		}
+		return an < bn
+	})
+}
+
+func populateRepos(ctx context.Context, f Fetcher, opts *options) ([]*repositoryData, error) {
+	result := []*repositoryData{}
+	if len(opts.Repositories) > 0 {
+		for _, repoName := range opts.Repositories {
+			owner, name := splitRepo(opts.Selector, repoName)
+			repoData, err := f.Repo(ctx, owner, name)
+			if isNotFound(err) {
+				err = fmt.Errorf("repository %s/%s not found", owner, name)
+			} else if err != nil {
+				err = fmt.Errorf("failed to fetch data for %s/%s: %w", owner, name, err)
+			}
+			if isCutShort(err) {
+				warnCutShort(opts, err)
+				break
+			}
+			if err != nil {
+				if opts.Strict || errors.Is(err, errNotAuthenticated) {
+					return nil, err
 				}
-				bp := billablePayload{}
-				err = json.Unmarshal(stdout.Bytes(), &bp)
-				if err != nil {
-					return nil, fmt.Errorf("could not parse json: %w", err)
+				// Carry on with the rest; a typo shouldn't hide every
+				// other repository.
+				warnings.add("skipped: %s", err)
+				continue
+			}
+			result = append(result, repoData)
+		}
+	} else {
+		seen := map[string]bool{}
+		for _, selector := range opts.Selectors {
+			repos, err := f.Repos(ctx, selector, opts.Limit)
+			if err != nil {
+				return nil, err
+			}
+			for _, r := range repos {
+				if r.Archived && !opts.IncludeArchived {
+					// Named with --repos they're shown regardless.
+					logger.debugf("%s: archived, skipping", r.Name)
+					continue
 				}
+				// The same repository can't come from two owners, but the
+				// same owner can be given twice.
+				if !seen[r.Name] {
+					seen[r.Name] = true
+					result = append(result, r)
+				}
+			}
+		}
+	}
+
+	result = filterReposByVisibility(result, opts.Visibility)
+	result = excludeRepos(result, opts.ExcludeRepos)
+
+	if opts.Sort != sortNone {
+		sortReposByName(result)
+	}
+
+	return result, nil
+}
+
+const (
+	visibilityPrivate = "private"
+	visibilityPublic  = "public"
+)
+
+// filterReposByVisibility keeps only private or only public repos; an empty
+// visibility keeps everything.
+func filterReposByVisibility(repos []*repositoryData, visibility string) []*repositoryData {
+	if visibility == "" {
+		return repos
+	}
+
+	out := []*repositoryData{}
+	for _, r := range repos {
+		if r.Private == (visibility == visibilityPrivate) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
 
-				totalMs += bp.MacOs.TotalMs + bp.Windows.TotalMs + bp.Ubuntu.TotalMs
+// excludeRepos drops repos matching any of the patterns, ignoring case. A
+// pattern without a slash is matched against the repository's name alone
+// and one with a slash against owner/name; either may be a glob (eg
+// sandbox-*).
+func excludeRepos(repos []*repositoryData, patterns []string) []*repositoryData {
+	if len(patterns) == 0 {
+		return repos
+	}
+
+	out := []*repositoryData{}
+	for _, r := range repos {
+		if !matchesRepo(r.Name, patterns) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// matchesRepo reports whether the owner/name fullName matches any of the
+// patterns, as described for excludeRepos.
+func matchesRepo(fullName string, patterns []string) bool {
+	fullName = strings.ToLower(fullName)
+	_, name := splitRepo("", fullName)
+	for _, p := range patterns {
+		p = strings.ToLower(p)
+		subject := name
+		if strings.Contains(p, "/") {
+			subject = fullName
+		}
+		if ok, _ := path.Match(p, subject); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+var validRepoRE = regexp.MustCompile(`^([^/\s]+/)?[^/\s]+$`)
+
+// splitRepo splits an "owner/name" repository into its parts. Bare names
+// belong to defaultOwner.
+func splitRepo(defaultOwner, repo string) (owner, name string) {
+	if i := strings.Index(repo, "/"); i >= 0 {
+		return repo[:i], repo[i+1:]
+	}
+	return defaultOwner, repo
+}
+
+// readReposFile reads one repository per line, either "name" or
+// "owner/name". Blank lines and anything after a # are ignored.
+func readReposFile(r io.Reader) ([]string, error) {
+	repos := []string{}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		repos = append(repos, line)
+	}
+
+	return repos, scanner.Err()
+}
+
+// sortReposByName orders repos alphabetically ignoring case so that the
+// dashboard layout is stable between runs.
+func sortReposByName(repos []*repositoryData) {
+	sort.SliceStable(repos, func(i, j int) bool {
+		a, b := strings.ToLower(repos[i].Name), strings.ToLower(repos[j].Name)
+		if a != b {
+			return a < b
+		}
+		return repos[i].Name < repos[j].Name
+	})
+}
+
+type workflowsPayload struct {
+	Id        int `json:"id"`
+	State     string
+	Name      string
+	Path      string    `json:"path"`
+	URL       string    `json:"url"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type runPayload struct {
+	Id           int       `json:"id"`
+	RunNumber    int       `json:"run_number"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	RunStartedAt time.Time `json:"run_started_at"`
+	Status       string
+	Conclusion   string
+	Event        string
+	HeadBranch   string `json:"head_branch"`
+	URL          string
+	HTMLURL      string `json:"html_url"`
+}
+
+// validEvents are the events that can trigger a workflow run, for --event.
+var validEvents = []string{
+	"branch_protection_rule", "check_run", "check_suite", "create", "delete",
+	"deployment", "deployment_status", "discussion", "discussion_comment",
+	"dynamic", "fork", "gollum", "issue_comment", "issues", "label",
+	"merge_group", "milestone", "page_build", "public", "pull_request",
+	"pull_request_review", "pull_request_review_comment",
+	"pull_request_target", "push", "registry_package", "release",
+	"repository_dispatch", "schedule", "status", "watch", "workflow_call",
+	"workflow_dispatch", "workflow_run",
+}
+
+// minRunsFetched is how many runs are fetched per workflow even when
+// --max-runs is lower, so success rates and averages over the window have
+// the API's default page of runs to work with.
+const minRunsFetched = 30
+
+// runsFetched is how many of a workflow's most recent runs are fetched: at
+// least --max-runs, so that the health strip can be filled, and never fewer
+// than minRunsFetched.
+func runsFetched(opts *options) int {
+	if opts.MaxRuns > minRunsFetched {
+		return opts.MaxRuns
+	}
+	return minRunsFetched
+}
+
+// runsQuery builds the query string narrowing the workflow runs endpoint to
+// the runs the user asked for.
+func runsQuery(opts *options) string {
+	q := url.Values{}
+	if opts.Branch != "" {
+		q.Set("branch", opts.Branch)
+	}
+	if opts.Actor != "" {
+		q.Set("actor", opts.Actor)
+	}
+	if opts.Event != "" {
+		q.Set("event", opts.Event)
+	}
+	return q.Encode()
+}
+
+type billablePayload struct {
+	MacOs struct {
+		TotalMs int `json:"total_ms"`
+	} `json:"MACOS"`
+	Windows struct {
+		TotalMs int `json:"total_ms"`
+	} `json:"WINDOWS"`
+	Ubuntu struct {
+		TotalMs int `json:"total_ms"`
+	} `json:"UBUNTU"`
+}
+
+// listWorkflows fetches every workflow in a repository, or reuses the list
+// from workflowLists.
+func listWorkflows(ctx context.Context, f Fetcher, repo string) ([]workflowsPayload, error) {
+	now := time.Now()
+	if workflows, ok := workflowLists.get(repo, now); ok {
+		logger.debugf("%s: reusing workflow list", repo)
+		return workflows, nil
+	}
+
+	workflows, err := f.Workflows(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	workflowLists.put(repo, workflows, now)
+	return workflows, nil
+}
+
+const (
+	billableOSAll     = "all"
+	billableOSMacOS   = "macos"
+	billableOSWindows = "windows"
+	billableOSUbuntu  = "ubuntu"
+)
+
+var validBillableOSes = []string{billableOSAll, billableOSMacOS, billableOSWindows, billableOSUbuntu}
+
+// only zeroes the billable time of every operating system but os, for
+// --billable-os. billableOSAll keeps everything.
+func (bp billablePayload) only(os string) billablePayload {
+	if os != billableOSAll && os != billableOSMacOS {
+		bp.MacOs.TotalMs = 0
+	}
+	if os != billableOSAll && os != billableOSWindows {
+		bp.Windows.TotalMs = 0
+	}
+	if os != billableOSAll && os != billableOSUbuntu {
+		bp.Ubuntu.TotalMs = 0
+	}
+	return bp
+}
+
+func getWorkflows(ctx context.Context, f Fetcher, repoData repositoryData, opts *options) ([]*workflow, error) {
+	if opts.DefaultBranchOnly {
+		branchOpts := *opts
+		branchOpts.Branch = repoData.DefaultBranch
+		opts = &branchOpts
+	}
+
+	workflows, err := listWorkflows(ctx, f, repoData.Name)
+	if isNotFound(err) {
+		// Repositories with Actions disabled have no workflows endpoint.
+		logger.debugf("%s: Actions not enabled, skipping", repoData.Name)
+		return []*workflow{}, nil
+	} else if isCutShort(err) {
+		return []*workflow{}, err
+	} else if err != nil {
+		return nil, err
+	}
+
+	active := []workflowsPayload{}
+	for _, w := range workflows {
+		if strings.HasPrefix(w.State, "disabled") && !opts.IncludeDisabled {
+			continue
+		}
+		if !matchesWorkflow(w.Name, opts.Workflows) || !matchesWorkflowFile(w, opts.WorkflowIDs, opts.WorkflowFiles) {
+			continue
+		}
+		active = append(active, w)
+	}
+
+	// Workflows are fetched by a pool of --max-concurrency workers, and
+	// apiLimiter bounds how many calls are actually in flight. Results land
+	// at the workflow's index so the API order is preserved.
+	out := make([]*workflow, len(active))
+	errs := make([]error, len(active))
+
+	runPool(opts.MaxConcurrency, len(active), func(i int) {
+		w := active[i]
+		if strings.HasPrefix(w.State, "disabled") {
+			// Listed for reference only; their runs would skew billable
+			// time and health.
+			out[i] = &workflow{Name: w.Name, MaxRuns: opts.MaxRuns, Disabled: true}
+			return
+		}
+		out[i], errs[i] = getWorkflow(ctx, f, repoData, w, opts)
+	})
+
+	// Running out of API calls or time leaves the workflows fetched so far,
+	// which are returned along with the error.
+	var cutShortErr error
+	fetched := []*workflow{}
+	for i, err := range errs {
+		if isCutShort(err) {
+			cutShortErr = err
+		} else if err != nil {
+			return nil, err
+		} else {
+			fetched = append(fetched, out[i])
+		}
+	}
+
+	return fetched, cutShortErr
+}
+
+// matchesWorkflow reports whether name matches any of the filters, ignoring
+// case. Filters containing glob characters are matched as globs (eg
+// "deploy-*"), others as substrings. No filters match everything.
+func matchesWorkflow(name string, filters []string) bool {
+	if len(filters) == 0 {
+		return true
+	}
+
+	name = strings.ToLower(name)
+	for _, f := range filters {
+		f = strings.ToLower(f)
+		if strings.ContainsAny(f, "*?[") {
+			if ok, _ := path.Match(f, name); ok {
+				return true
 			}
+		} else if strings.Contains(name, f) {
+			return true
 		}
+	}
 
-		out = append(out, &workflow{
-			Name:       w.Name,
-			Runs:       runs,
-			BillableMs: totalMs,
-		})
+	return false
+}
+
+// matchesWorkflowFile reports whether w has any of the ids or, by base name
+// (eg ci.yml) or full path (eg .github/workflows/ci.yml), any of the files.
+// This tells apart workflows that share a name. No ids or files match
+// everything.
+func matchesWorkflowFile(w workflowsPayload, ids []int, files []string) bool {
+	if len(ids) == 0 && len(files) == 0 {
+		return true
 	}
 
-	return out, nil
+	for _, id := range ids {
+		if w.Id == id {
+			return true
+		}
+	}
+	for _, f := range files {
+		if w.Path == f || path.Base(w.Path) == f {
+			return true
+		}
+	}
+
+	return false
 }
 
-func parseArgs() (*options, error) {
-	repositories := flag.StringSliceP("repos", "r", []string{}, "One or more repository names from the provided org or user")
-	last := flag.StringP("last", "l", "30d", "What period of time to cover in hours (eg 1h) or days (eg 30d). Default: 30d")
+// getWorkflow fetches the runs for a single workflow along with any billable
+// timing and artifact data that was asked for.
+func getWorkflow(ctx context.Context, f Fetcher, repoData repositoryData, w workflowsPayload, opts *options) (*workflow, error) {
+	var totalMs, macOsMs, windowsMs, ubuntuMs int
 
-	flag.Parse()
+	payloads, err := f.Runs(ctx, w, runsQuery(opts), runsFetched(opts))
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch runs: %w", err)
+	}
+
+	runs := []run{}
+	inProgress := []run{}
+
+	for _, r := range payloads {
+		if opts.Branch != "" && r.HeadBranch != opts.Branch {
+			continue
+		}
+
+		rr := run{Number: r.RunNumber, Status: r.Status, Conclusion: r.Conclusion, Event: r.Event, URL: r.URL, HTMLURL: r.HTMLURL}
+
+		// created_at includes time spent waiting for a runner, so measure
+		// from when the run actually started where the API says.
+		started := r.RunStartedAt
+		if started.IsZero() {
+			started = r.CreatedAt
+		} else {
+			rr.Queued = started.Sub(r.CreatedAt)
+			rr.QueueKnown = true
+		}
+
+		if r.Status == "completed" {
+			rr.Finished = r.UpdatedAt
+			rr.Elapsed = r.UpdatedAt.Sub(started)
+
+			if rr.Finished.After(opts.cutoff()) {
+				runs = append(runs, rr)
+			}
+		} else {
+			rr.Elapsed = time.Since(started).Round(time.Second)
+			inProgress = append(inProgress, rr)
+			if opts.IncludeRunning {
+				runs = append(runs, rr)
+			}
+		}
+	}
+
+	logger.debugf("%s: %s: %d runs fetched, %d in window, %d in progress",
+		repoData.Name, w.Name, len(payloads), len(runs), len(inProgress))
+
+	if repoData.Private && !opts.NoBillable {
+		timings, err := getRunTimings(ctx, f, runs, opts.MaxConcurrency)
+		if err != nil {
+			return nil, err
+		}
+
+		for i, bp := range timings {
+			bp = bp.only(opts.BillableOS)
+			runs[i].BillableMs = bp.MacOs.TotalMs + bp.Windows.TotalMs + bp.Ubuntu.TotalMs
+			totalMs += runs[i].BillableMs
+			macOsMs += bp.MacOs.TotalMs
+			windowsMs += bp.Windows.TotalMs
+			ubuntuMs += bp.Ubuntu.TotalMs
+		}
+	}
+
+	var latestFailure *run
+	if opts.Artifacts {
+		for i, r := range runs {
+			if !r.failed() {
+				continue
+			}
+			artifacts, err := f.Artifacts(ctx, r)
+			if err != nil {
+				return nil, fmt.Errorf("could not fetch artifacts: %w", err)
+			}
+			runs[i].Artifacts = artifacts
+			latestFailure = &runs[i]
+			break
+		}
+	}
+
+	return &workflow{
+		Name:          w.Name,
+		Runs:          runs,
+		InProgress:    inProgress,
+		BillableMs:    totalMs,
+		LatestFailure: latestFailure,
+		MaxRuns:       opts.MaxRuns,
+
+		BillableMacOsMs:   macOsMs,
+		BillableWindowsMs: windowsMs,
+		BillableUbuntuMs:  ubuntuMs,
+	}, nil
+}
+
+// getRunTimings fetches the billable time of each run on a pool of workers
+// goroutines, returning them in the same order as runs. As with workflows,
+// apiLimiter bounds how many calls are in flight; the caller sums the results
+// once all are in.
+func getRunTimings(ctx context.Context, f Fetcher, runs []run, workers int) ([]billablePayload, error) {
+	out := make([]billablePayload, len(runs))
+	errs := make([]error, len(runs))
+
+	progress.addTimings(len(runs))
+	runPool(workers, len(runs), func(i int) {
+		defer progress.timingDone()
+		timing, err := f.Timing(ctx, runs[i])
+		if err != nil {
+			errs[i] = fmt.Errorf("could not fetch run timing: %w", err)
+			return
+		}
+		out[i] = timing
+	})
 
-	if len(flag.Args()) != 1 {
-		return nil, errors.New("need exactly one argument, either an organization or user name")
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	lastVal := *last
-	timeUnit := string(lastVal[len(lastVal)-1])
+	return out, nil
+}
+
+// lastUnits are the --last suffixes Go cannot parse, with their length in
+// hours.
+var lastUnits = []struct {
+	suffix string
+	hours  int
+}{
+	{"mo", 30 * 24},
+	{"w", 7 * 24},
+	{"d", 24},
+}
+
+// parseLast parses a --last value in hours (1h), days (30d), weeks (4w) or
+// months of 30 days (2mo).
+func parseLast(lastVal string) (time.Duration, error) {
+	given := lastVal
 
 	// Go cannot parse duration "1d" which is stupid; need to convert it to hours before we can get a proper duration.
-	if timeUnit == "d" {
-		asNum, err := strconv.Atoi(lastVal[0 : len(lastVal)-1])
+	for _, u := range lastUnits {
+		if !strings.HasSuffix(lastVal, u.suffix) {
+			continue
+		}
+		asNum, err := strconv.Atoi(strings.TrimSuffix(lastVal, u.suffix))
 		if err != nil {
-			return nil, fmt.Errorf("could not parse number: %w", err)
+			return 0, fmt.Errorf("could not parse number: %w", err)
 		}
-		lastVal = fmt.Sprintf("%dh", asNum*24)
+		lastVal = fmt.Sprintf("%dh", asNum*u.hours)
+		break
 	}
 
-	if timeUnit != "h" && timeUnit != "d" {
-		return nil, fmt.Errorf("report duration should be in hours or duration (eg 1h or 30d)")
+	if !strings.HasSuffix(lastVal, "h") {
+		return 0, fmt.Errorf("report duration should be in hours, days, weeks or months (eg 1h, 30d, 4w or 2mo)")
 	}
 
 	duration, err := time.ParseDuration(lastVal)
-
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse duration: %w", err)
+		return 0, fmt.Errorf("failed to parse duration: %w", err)
+	}
+	if duration <= 0 {
+		return 0, fmt.Errorf("report duration must be positive, got '%s'", given)
 	}
 
-	return &options{
-		Repositories: *repositories,
-		Last:         duration,
-		Selector:     flag.Arg(0),
-	}, nil
+	return duration, nil
 }
 
-func main() {
-	opts, err := parseArgs()
+// parseSince accepts an RFC3339 timestamp or a YYYY-MM-DD date, which is
+// taken as local midnight.
+func parseSince(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since '%s'; expected a date like 2024-01-01 or an RFC3339 time", value)
+}
+
+// parseArgs parses command line arguments, not including the program name,
+// into options.
+func parseArgs(args []string) (*options, error) {
+	fs := flag.NewFlagSet("actions-dashboard", flag.ContinueOnError)
+
+	repositories := fs.StringSliceP("repos", "r", []string{}, "One or more repository names from the first given org or user, or owner/name for repositories elsewhere")
+	excludeRepoPatterns := fs.StringArray("exclude-repos", []string{}, "Skip repositories with this name, or owner/name, or matching this glob (eg sandbox-*); repeatable")
+	reposFile := fs.String("repos-file", "", "Read repository names, one per line as name or owner/name, from this file (- for stdin)")
+	last := fs.StringP("last", "l", "30d", "What period of time to cover in hours (eg 1h), days (eg 30d), weeks (eg 4w) or months (eg 2mo). Default: 30d")
+	since := fs.String("since", "", "Only consider runs finished after this date (eg 2024-01-01) or RFC3339 time, instead of --last")
+	format := fs.String("format", formatCards, fmt.Sprintf("Output format: %s", strings.Join(validFormats, ", ")))
+	sortBy := fs.String("sort", sortName, "How to order workflows: name, elapsed, health (failures), billable, or none to keep API order. Repositories are ordered by name unless none")
+	reverse := fs.Bool("reverse", false, "Reverse the --sort order of workflows")
+	cacheTTL := fs.Duration("cache-ttl", defaultApiCacheTime, "How long to reuse dashboard data saved to disk by a previous run")
+	noCache := fs.Bool("no-cache", false, "Fetch fresh data instead of reusing the on-disk dashboard cache")
+	billableOS := fs.String("billable-os", billableOSAll, fmt.Sprintf("Only count billable time on one operating system: %s", strings.Join(validBillableOSes, ", ")))
+	noBillable := fs.Bool("no-billable", false, "Skip fetching billable time for private repositories (one API call per run)")
+	showEmpty := fs.Bool("show-empty", false, "List repositories without any workflows instead of skipping them")
+	includeDisabled := fs.Bool("include-disabled", false, "Also list disabled workflows, labelled as such")
+	includeArchived := fs.Bool("include-archived", false, "Also show the org or user's archived repositories, which are skipped by default")
+	privateOnly := fs.Bool("private-only", false, "Only show private repositories, whose runs are billed")
+	publicOnly := fs.Bool("public-only", false, "Only show public repositories")
+	strict := fs.Bool("strict", false, "Fail if any --repos repository can't be fetched instead of skipping it")
+	slow := fs.Duration("slow-threshold", defaultSlowThreshold, "Average elapsed time from which a workflow is shown as slow")
+	verySlow := fs.Duration("very-slow-threshold", defaultVerySlowThreshold, "Average elapsed time from which a workflow is shown as very slow")
+	includeRunning := fs.Bool("include-running", false, "Count queued and in-progress runs in the health strip and average elapsed")
+	verbose := fs.BoolP("verbose", "v", false, "Log API calls, run counts and cache use to stderr")
+	quiet := fs.BoolP("quiet", "q", false, "Leave out the title, legend, summary and repository links, printing only grouped cards or table rows")
+	glyphSuccess := fs.String("glyph-success", defaultGlyphs.Success, "Health strip glyph for successful runs")
+	glyphFailure := fs.String("glyph-failure", defaultGlyphs.Failed, "Health strip glyph for failed runs")
+	glyphNeutral := fs.String("glyph-neutral", defaultGlyphs.Neutral, "Health strip glyph for skipped, cancelled and unfinished runs")
+	config := fs.String("config", "", "Read default flag values from this YAML file (default: actions-dashboard/config.yml in the user config directory, eg ~/.config on Linux or ~/Library/Application Support on macOS)")
+	maxAPICalls := fs.Int("max-api-calls", 0, "Stop fetching after this many API calls and show what was collected (0 for no limit)")
+	timeout := fs.Duration("timeout", 0, "Stop fetching after this long and show what was collected, eg 2m; in --watch and --stream, per poll (0 for no limit)")
+	retries := fs.Int("retries", defaultRetries, "How many times to retry API calls that hit rate limits, 5xx responses or network errors")
+	showQueueFlag := fs.Bool("show-queue", false, "Show how long runs waited for a runner on average")
+	dryRun := fs.Bool("dry-run", false, "List the API calls fetching the dashboard would make, with an estimated count, without making any")
+	compactHealthFlag := fs.Bool("compact-health", false, "Collapse streaks of three or more identical runs in health strips, eg ✓×5")
+	emphasizeLatestFlag := fs.Bool("emphasize-latest", false, "Bracket and bold the most recent run in health strips, eg [x]✓✓")
+	percentilesFlag := fs.Bool("percentiles", false, "Show median (p50) and 95th percentile (p95) elapsed time on cards")
+	detailedFlag := fs.Bool("detailed", false, "Show how many runs succeeded, failed, were cancelled or skipped on each card")
+	elapsedFmt := fs.String("elapsed-format", elapsedFormatGo, "How cards show durations: go (1m30s), clock (01:30) or human (1 min 30 sec)")
+	minRuns := fs.Int("min-runs", 0, "Hide workflows with fewer than this many runs in the selected period")
+	top := fs.Int("top", 0, "Only show the N least healthy workflows across all repositories, ranked by failure rate and then most recent failure (0 shows everything)")
+	topPerRepo := fs.Bool("top-per-repo", false, "Apply --top within each repository instead of across all of them")
+	failOnError := fs.Bool("fail-on-error", false, "Exit non-zero if any shown workflow's most recent run failed")
+	failThreshold := fs.Float64("fail-threshold", 0, "Exit non-zero if any shown workflow's success rate is below this percentage (0-100)")
+	statusJSON := fs.String("status-json", "", "After rendering, write workflow counts by health and the exit code with its reasons as JSON to this file (- for stderr)")
+	stream := fs.Bool("stream", false, "Poll repeatedly, printing a line only when a workflow's status changes")
+	interval := fs.Duration("interval", defaultInterval, "How often to poll when streaming or watching (eg 30s, 5m)")
+	watch := fs.Bool("watch", false, "Redraw the dashboard full screen every --interval until interrupted")
+	otlpEndpoint := fs.String("otlp-endpoint", "", "OTLP/HTTP collector to push metrics to with --format otlp (eg http://localhost:4318)")
+	artifacts := fs.Bool("artifacts", false, "Show the artifact count and link for each workflow's latest failing run (extra API calls)")
+
+	repoCards := fs.Bool("repo-cards", false, "Render one summary card per repository instead of one per workflow")
+	groupBy := fs.String("group-by", groupByRepo, "Group cards and table rows by repo, or by workflow to compare a workflow across repositories")
+	bom := fs.Bool("bom", false, "Prefix --format csv output with a UTF-8 byte order mark so Excel reads names correctly")
+	theme := fs.String("theme", themeDark, fmt.Sprintf("Color theme: %s", strings.Join(validThemes, ", ")))
+	colorSuccess := fs.String("color-success", "", "Color for successful runs, hex or ANSI code (env: ACTIONS_DASHBOARD_COLOR_SUCCESS)")
+	colorFail := fs.String("color-fail", "", "Color for failed runs, hex or ANSI code (env: ACTIONS_DASHBOARD_COLOR_FAIL)")
+	colorNeutral := fs.String("color-neutral", "", "Color for skipped, cancelled and unfinished runs, hex or ANSI code (env: ACTIONS_DASHBOARD_COLOR_NEUTRAL)")
+	colorBorder := fs.String("color-border", "", "Color for card borders, hex or ANSI code (env: ACTIONS_DASHBOARD_COLOR_BORDER)")
+	inventory := fs.Bool("inventory", false, "List every workflow with its state, path and last run instead of health (fetches only each workflow's latest run)")
+	concurrency := fs.IntP("concurrency", "c", defaultConcurrency, "How many API calls to run in parallel to begin with")
+	minConcurrency := fs.Int("min-concurrency", defaultMinConcurrency, "Fewest API calls to keep in flight when backing off from rate limits")
+	maxConcurrency := fs.Int("max-concurrency", defaultMaxConcurrency, "Most API calls to run in parallel when ramping up without rate limit pressure")
+	asJSON := fs.BoolP("json", "j", false, "Output JSON for scripting; shorthand for --format json")
+	asTable := fs.BoolP("table", "t", false, "One line per workflow instead of cards; shorthand for --format table")
+	asMarkdown := fs.Bool("markdown", false, "Output Markdown tables for pasting into issues; shorthand for --format markdown")
+	asMD := fs.Bool("md", false, "Alias for --markdown")
+	asJSONL := fs.Bool("jsonl", false, "Output one JSON object per workflow per line, as each repository is fetched; shorthand for --format jsonl")
+	listRuns := fs.Bool("list-runs", false, "List each workflow's runs with their number, conclusion, elapsed time, finish time and link instead of a health strip; shorthand for --format runs")
+	asCSV := fs.Bool("csv", false, "Output one CSV row per workflow for spreadsheets; shorthand for --format csv")
+	asHTML := fs.Bool("html", false, "Output a self-contained HTML page for publishing; shorthand for --format html")
+	_ = fs.MarkHidden("md")
+	host := fs.StringP("host", "H", "", "GitHub host to query, eg a GitHub Enterprise Server hostname (default: gh's default host)")
+	branch := fs.StringP("branch", "b", "", "Only consider runs on this branch (default: all branches)")
+	defaultBranchOnly := fs.Bool("default-branch-only", false, "Only consider runs on each repository's default branch")
+	actor := fs.String("actor", "", "Only consider runs triggered by this user; combines with --branch")
+	event := fs.String("event", "", "Only consider runs triggered by this event, eg push, pull_request or schedule")
+	noColor := fs.Bool("no-color", false, "Disable colors and text styling (also set by NO_COLOR)")
+	width := fs.Int("width", 0, "Render for this many columns instead of $COLUMNS or the terminal's width")
+	workflows := fs.StringArrayP("workflow", "w", []string{}, "Only show workflows whose name contains this text or matches this glob (eg deploy-*); repeatable")
+	workflowIDs := fs.IntSlice("workflow-id", []int{}, "Only show workflows with this numeric ID; repeatable")
+	workflowFiles := fs.StringArray("workflow-file", []string{}, "Only show workflows defined in this file, eg ci.yml or .github/workflows/ci.yml; repeatable")
+	maxRuns := fs.IntP("max-runs", "n", defaultMaxRuns, "How many of the most recent runs the health strip and average elapsed cover")
+	output := fs.StringP("output", "o", "", "Write output to this file instead of stdout")
+	limit := fs.Int("limit", 0, "Fetch at most this many repositories from the org or user (default: all)")
+	showVersion := fs.Bool("version", false, "Print the version and exit")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	// Remember what was given on the command line, as opposed to the config
+	// file, so that conflicting flags resolve in the command line's favor.
+	onCommandLine := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		onCommandLine[f.Name] = true
+	})
+
+	configFile := *config
+	if configFile == "" {
+		configFile = configPath()
+	}
+	settings, err := loadConfig(configFile, *config != "")
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "failed to parse arguments: %s\n", err)
-		os.Exit(1)
+		return nil, err
+	}
+	if err := applyConfig(fs, settings); err != nil {
+		return nil, err
+	}
+	if err := clearConfigConflicts(fs, onCommandLine); err != nil {
+		return nil, err
+	}
+
+	if *showVersion {
+		return &options{ShowVersion: true}, nil
+	}
+
+	if len(fs.Args()) < 1 {
+		return nil, errors.New("need at least one argument, an organization or user name")
 	}
 
-	// TODO testing is annoying bc of flag.Parse() in _main
-	err = _main(opts)
+	duration, err := parseLast(*last)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "%s\n", err)
-		os.Exit(1)
+		return nil, err
+	}
+
+	var sinceTime time.Time
+	if *defaultBranchOnly && *branch != "" {
+		return nil, errors.New("--default-branch-only and --branch cannot be combined")
+	}
+
+	if *since != "" {
+		if fs.Changed("last") {
+			return nil, errors.New("--since and --last cannot be combined")
+		}
+		sinceTime, err = parseSince(*since)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	formatShorthands := []struct {
+		flag   string
+		set    bool
+		format string
+	}{
+		{"json", *asJSON, formatJSON},
+		{"table", *asTable, formatTable},
+		{"markdown", *asMarkdown || *asMD, formatMarkdown},
+		{"html", *asHTML, formatHTML},
+		{"csv", *asCSV, formatCSV},
+		{"jsonl", *asJSONL, formatJSONL},
+		{"list-runs", *listRuns, formatRuns},
+	}
+	shorthandUsed := false
+	for _, sh := range formatShorthands {
+		if !sh.set {
+			continue
+		}
+		if (fs.Changed("format") || shorthandUsed) && *format != sh.format {
+			return nil, fmt.Errorf("--%s cannot be combined with another --format", sh.flag)
+		}
+		*format = sh.format
+		shorthandUsed = true
+	}
+
+	if !isOneOf(*format, validFormats) {
+		return nil, fmt.Errorf("unknown format '%s'; expected one of: %s", *format, strings.Join(validFormats, ", "))
+	}
+
+	if !isOneOf(*sortBy, validSorts) {
+		return nil, fmt.Errorf("unknown sort '%s'; expected one of: %s", *sortBy, strings.Join(validSorts, ", "))
+	}
+
+	if !isOneOf(*billableOS, validBillableOSes) {
+		return nil, fmt.Errorf("unknown billable-os '%s'; expected one of: %s", *billableOS, strings.Join(validBillableOSes, ", "))
 	}
+
+	if !isOneOf(*groupBy, validGroupBys) {
+		return nil, fmt.Errorf("unknown group-by '%s'; expected one of: %s", *groupBy, strings.Join(validGroupBys, ", "))
+	}
+
+	if !isOneOf(*elapsedFmt, validElapsedFormats) {
+		return nil, fmt.Errorf("unknown elapsed format '%s'; expected one of: %s", *elapsedFmt, strings.Join(validElapsedFormats, ", "))
+	}
+
+	if *bom && *format != formatCSV {
+		return nil, errors.New("--bom only applies to --format csv")
+	}
+
+	if *groupBy == groupByWorkflow && (*format != formatCards && *format != formatTable || *repoCards) {
+		return nil, errors.New("--group-by workflow only applies to workflow cards and --format table")
+	}
+
+	if *format == formatOTLP && *otlpEndpoint == "" {
+		return nil, errors.New("--format otlp requires --otlp-endpoint")
+	}
+
+	if !isOneOf(*theme, validThemes) {
+		return nil, fmt.Errorf("unknown theme '%s'; expected one of: %s", *theme, strings.Join(validThemes, ", "))
+	}
+
+	// Individual colors override the theme's.
+	pal := themes[*theme]
+	if pal.Success, err = resolveColor("color-success", *colorSuccess, pal.Success); err != nil {
+		return nil, err
+	}
+	if pal.Failed, err = resolveColor("color-fail", *colorFail, pal.Failed); err != nil {
+		return nil, err
+	}
+	if pal.Neutral, err = resolveColor("color-neutral", *colorNeutral, pal.Neutral); err != nil {
+		return nil, err
+	}
+	if pal.Border, err = resolveColor("color-border", *colorBorder, pal.Border); err != nil {
+		return nil, err
+	}
+
+	if !fs.Changed("max-concurrency") && *concurrency > *maxConcurrency {
+		*maxConcurrency = *concurrency
+	}
+
+	if *minConcurrency < 1 || *concurrency < *minConcurrency || *maxConcurrency < *concurrency {
+		return nil, errors.New("concurrency must satisfy 1 <= --min-concurrency <= --concurrency <= --max-concurrency")
+	}
+
+	for _, w := range *workflows {
+		if _, err := path.Match(w, ""); err != nil {
+			return nil, fmt.Errorf("invalid workflow pattern '%s': %w", w, err)
+		}
+	}
+
+	if *maxRuns < 1 {
+		return nil, errors.New("max-runs must be at least 1")
+	}
+
+	if *limit < 0 {
+		return nil, errors.New("limit must not be negative")
+	}
+
+	if *width < 0 {
+		return nil, errors.New("width must not be negative")
+	}
+
+	if *interval <= 0 {
+		return nil, errors.New("interval must be greater than zero")
+	}
+
+	if *watch && *stream {
+		return nil, errors.New("--watch and --stream cannot be combined")
+	}
+
+	if *watch && *output != "" {
+		return nil, errors.New("--watch draws to the terminal and cannot be combined with --output")
+	}
+
+	if *slow <= 0 || *verySlow < *slow {
+		return nil, errors.New("slow-threshold must be greater than zero and no more than very-slow-threshold")
+	}
+
+	if *reposFile != "" {
+		var r io.Reader = os.Stdin
+		if *reposFile != "-" {
+			f, err := os.Open(*reposFile)
+			if err != nil {
+				return nil, fmt.Errorf("could not open repos file: %w", err)
+			}
+			defer f.Close()
+			r = f
+		}
+		fromFile, err := readReposFile(r)
+		if err != nil {
+			return nil, fmt.Errorf("could not read repos file: %w", err)
+		}
+		*repositories = append(*repositories, fromFile...)
+	}
+
+	for _, repo := range *repositories {
+		if !validRepoRE.MatchString(repo) {
+			return nil, fmt.Errorf("invalid repository '%s'; expected name or owner/name", repo)
+		}
+	}
+
+	if *event != "" && !isOneOf(*event, validEvents) {
+		return nil, fmt.Errorf("unknown event '%s'; expected one of: %s", *event, strings.Join(validEvents, ", "))
+	}
+
+	if *maxAPICalls < 0 {
+		return nil, errors.New("max-api-calls must not be negative")
+	}
+
+	if *timeout < 0 {
+		return nil, errors.New("timeout must not be negative")
+	}
+
+	if *retries < 0 {
+		return nil, errors.New("retries must not be negative")
+	}
+
+	if *failThreshold < 0 || *failThreshold > 100 {
+		return nil, errors.New("fail-threshold must be between 0 and 100")
+	}
+
+	if *minRuns < 0 {
+		return nil, errors.New("min-runs must not be negative")
+	}
+
+	if *top < 0 {
+		return nil, errors.New("top must not be negative")
+	}
+
+	if *topPerRepo && *top == 0 {
+		return nil, errors.New("top-per-repo needs --top")
+	}
+
+	if *glyphSuccess == "" || *glyphFailure == "" || *glyphNeutral == "" {
+		return nil, errors.New("glyphs cannot be empty")
+	}
+
+	if *privateOnly && *publicOnly {
+		return nil, errors.New("--private-only and --public-only cannot be combined")
+	}
+
+	visibility := ""
+	if *privateOnly {
+		visibility = visibilityPrivate
+	} else if *publicOnly {
+		visibility = visibilityPublic
+	}
+
+	if *cacheTTL < 0 {
+		return nil, errors.New("cache-ttl must not be negative")
+	}
+
+	return &options{
+		Repositories:      *repositories,
+		Last:              duration,
+		Selector:          fs.Arg(0),
+		Selectors:         fs.Args(),
+		Format:            *format,
+		Stream:            *stream,
+		Interval:          *interval,
+		Artifacts:         *artifacts,
+		OTLPEndpoint:      *otlpEndpoint,
+		Sort:              *sortBy,
+		BOM:               *bom,
+		RepoCards:         *repoCards,
+		GroupBy:           *groupBy,
+		Colors:            pal,
+		Inventory:         *inventory,
+		Concurrency:       *concurrency,
+		MinConcurrency:    *minConcurrency,
+		MaxConcurrency:    *maxConcurrency,
+		Host:              *host,
+		Branch:            *branch,
+		DefaultBranchOnly: *defaultBranchOnly,
+		NoColor:           *noColor || os.Getenv("NO_COLOR") != "",
+		Width:             *width,
+		Workflows:         *workflows,
+		WorkflowIDs:       *workflowIDs,
+		WorkflowFiles:     *workflowFiles,
+		MaxRuns:           *maxRuns,
+		Output:            *output,
+		Limit:             *limit,
+		Reverse:           *reverse,
+		FailOnError:       *failOnError,
+		FailThreshold:     *failThreshold,
+		StatusJSON:        *statusJSON,
+		CacheTTL:          *cacheTTL,
+		NoCache:           *noCache,
+		NoBillable:        *noBillable,
+		BillableOS:        *billableOS,
+		IncludeDisabled:   *includeDisabled,
+		IncludeArchived:   *includeArchived,
+		ShowEmpty:         *showEmpty,
+		Visibility:        visibility,
+		ExcludeRepos:      *excludeRepoPatterns,
+		Watch:             *watch,
+		Strict:            *strict,
+		SlowThreshold:     *slow,
+		VerySlowThreshold: *verySlow,
+		Since:             sinceTime,
+		IncludeRunning:    *includeRunning,
+		Verbose:           *verbose,
+		Quiet:             *quiet,
+		Glyphs:            glyphSet{Success: *glyphSuccess, Neutral: *glyphNeutral, Failed: *glyphFailure},
+		Retries:           *retries,
+		MaxAPICalls:       *maxAPICalls,
+		Timeout:           *timeout,
+		Actor:             *actor,
+		Event:             *event,
+		ShowQueue:         *showQueueFlag,
+		Detailed:          *detailedFlag,
+		Percentiles:       *percentilesFlag,
+		CompactHealth:     *compactHealthFlag,
+		EmphasizeLatest:   *emphasizeLatestFlag,
+		DryRun:            *dryRun,
+		ElapsedFormat:     *elapsedFmt,
+		MinRuns:           *minRuns,
+		Top:               *top,
+		TopPerRepo:        *topPerRepo,
+	}, nil
 }
 
-// gh shells out to gh, returning STDOUT/STDERR and any error
-func gh(args ...string) (sout, eout bytes.Buffer, err error) {
-	ghBin, err := safeexec.LookPath("gh")
+// runCLI is the whole program short of exiting: it parses args, renders the
+// dashboard and returns the exit code.
+func runCLI(args []string, stdout, stderr io.Writer, newFetcher func(host string) (Fetcher, error)) int {
+	opts, err := parseArgs(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
 	if err != nil {
-		err = fmt.Errorf("could not find gh. Is it installed? error: %w", err)
-		return
+		fmt.Fprintf(stderr, "failed to parse arguments: %s\n", err)
+		return 1
 	}
 
-	cmd := exec.Command(ghBin, args...)
-	cmd.Stderr = &eout
-	cmd.Stdout = &sout
+	if opts.ShowVersion {
+		fmt.Fprintf(stdout, "actions-dashboard %s\n", buildVersion())
+		return 0
+	}
 
-	err = cmd.Run()
+	err = _main(opts, stdout, stderr, newFetcher)
 	if err != nil {
-		err = fmt.Errorf("failed to run gh. error: %w, stderr: %s", err, eout.String())
-		return
+		fmt.Fprintf(stderr, "%s\n", err)
+		return 1
 	}
 
-	return
+	return 0
+}
+
+func main() {
+	os.Exit(runCLI(os.Args[1:], os.Stdout, os.Stderr, newGHFetcher))
 }
@@ -0,0 +1,1322 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+	"github.com/muesli/termenv"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files under testdata with the output they're checked against")
+
+// checkGolden compares got with testdata/name, or rewrites that file with
+// got when run with -update.
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("could not read golden file; run go test -update to write it: %s", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s; run go test -update if that's intended\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+// finishedRun is a completed run that finished at a fixed time, so that
+// rendered output doesn't change from one test run to the next.
+func finishedRun(repo, conclusion, finished string, elapsed time.Duration, billableMs int) run {
+	at, err := time.Parse(time.RFC3339, finished)
+	if err != nil {
+		panic(err)
+	}
+	return run{
+		Status:     "completed",
+		Conclusion: conclusion,
+		Event:      "push",
+		Finished:   at,
+		Elapsed:    elapsed,
+		BillableMs: billableMs,
+		URL:        "https://api.github.com/repos/" + repo + "/actions/runs/" + at.Format("0102150405"),
+		HTMLURL:    "https://github.com/" + repo + "/actions/runs/" + at.Format("0102150405"),
+	}
+}
+
+// goldenDashboard is the dashboard rendered in golden file tests: a public
+// repository with more runs than the health strip shows and a workflow
+// without runs, a private one whose workflow name needs escaping, and one
+// without workflows.
+func goldenDashboard() []*repositoryData {
+	return []*repositoryData{
+		{
+			Name: "cli/cli",
+			Workflows: []*workflow{
+				{
+					Name: "CI",
+					Runs: []run{
+						finishedRun("cli/cli", "success", "2024-05-06T12:00:00Z", 90*time.Second, 0),
+						finishedRun("cli/cli", "failure", "2024-05-05T12:00:00Z", 2*time.Minute, 0),
+						finishedRun("cli/cli", "success", "2024-05-04T12:00:00Z", 80*time.Second, 0),
+						finishedRun("cli/cli", "cancelled", "2024-05-03T12:00:00Z", 10*time.Second, 0),
+						finishedRun("cli/cli", "success", "2024-05-02T12:00:00Z", 100*time.Second, 0),
+						finishedRun("cli/cli", "success", "2024-05-01T12:00:00Z", 10*time.Minute, 0),
+					},
+					MaxRuns: 5,
+				},
+				{Name: "Nightly", Runs: []run{}, MaxRuns: 5},
+			},
+		},
+		{
+			Name:    "cli/internal",
+			Private: true,
+			Workflows: []*workflow{
+				{
+					Name: `Deploy "prod", <eu>`,
+					Runs: []run{
+						finishedRun("cli/internal", "success", "2024-05-06T09:30:00Z", 5*time.Minute, 300000),
+						finishedRun("cli/internal", "failure", "2024-05-05T09:30:00Z", time.Minute, 60000),
+					},
+					BillableMs:       360000,
+					BillableUbuntuMs: 300000,
+					BillableMacOsMs:  60000,
+					MaxRuns:          5,
+				},
+			},
+		},
+		{Name: "cli/empty", Workflows: []*workflow{}},
+	}
+}
+
+// isolate keeps a test away from the user's config file, dashboard cache and
+// color settings.
+func isolate(t *testing.T) {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("NO_COLOR", "")
+	for _, name := range []string{"SUCCESS", "FAIL", "NEUTRAL", "BORDER"} {
+		t.Setenv("ACTIONS_DASHBOARD_COLOR_"+name, "")
+	}
+}
+
+// withColor renders in true color, or with color disabled as by --no-color,
+// until t ends.
+func withColor(t *testing.T, enabled bool) {
+	t.Helper()
+	profile, wasEnabled := lipgloss.ColorProfile(), colorEnabled
+	t.Cleanup(func() {
+		lipgloss.SetColorProfile(profile)
+		colorEnabled = wasEnabled
+	})
+
+	if enabled {
+		colorEnabled = true
+		lipgloss.SetColorProfile(termenv.TrueColor)
+	} else {
+		disableColor()
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		check   func(t *testing.T, o *options)
+		wantErr string
+	}{
+		{
+			name: "defaults",
+			args: []string{"cli"},
+			check: func(t *testing.T, o *options) {
+				if o.Selector != "cli" || o.Format != formatCards || o.Last != 30*24*time.Hour || o.MaxRuns != defaultMaxRuns {
+					t.Errorf("got selector %q, format %q, last %s, max runs %d", o.Selector, o.Format, o.Last, o.MaxRuns)
+				}
+			},
+		},
+		{
+			name: "several owners",
+			args: []string{"cli", "github"},
+			check: func(t *testing.T, o *options) {
+				if !reflect.DeepEqual(o.Selectors, []string{"cli", "github"}) {
+					t.Errorf("got selectors %v", o.Selectors)
+				}
+			},
+		},
+		{
+			name: "last in hours",
+			args: []string{"--last", "12h", "cli"},
+			check: func(t *testing.T, o *options) {
+				if o.Last != 12*time.Hour {
+					t.Errorf("got last %s", o.Last)
+				}
+			},
+		},
+		{
+			name: "last in weeks",
+			args: []string{"-l", "1w", "cli"},
+			check: func(t *testing.T, o *options) {
+				if o.Last != 7*24*time.Hour {
+					t.Errorf("got last %s", o.Last)
+				}
+			},
+		},
+		{
+			name: "last in months",
+			args: []string{"--last", "2mo", "cli"},
+			check: func(t *testing.T, o *options) {
+				if o.Last != 60*24*time.Hour {
+					t.Errorf("got last %s", o.Last)
+				}
+			},
+		},
+		{
+			name: "since a date",
+			args: []string{"--since", "2024-01-01", "cli"},
+			check: func(t *testing.T, o *options) {
+				if want := time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local); !o.Since.Equal(want) {
+					t.Errorf("got since %s, want %s", o.Since, want)
+				}
+			},
+		},
+		{
+			name: "format shorthand",
+			args: []string{"--json", "cli"},
+			check: func(t *testing.T, o *options) {
+				if o.Format != formatJSON {
+					t.Errorf("got format %q", o.Format)
+				}
+			},
+		},
+		{
+			name: "md alias",
+			args: []string{"--md", "cli"},
+			check: func(t *testing.T, o *options) {
+				if o.Format != formatMarkdown {
+					t.Errorf("got format %q", o.Format)
+				}
+			},
+		},
+		{
+			name: "list runs",
+			args: []string{"--list-runs", "cli"},
+			check: func(t *testing.T, o *options) {
+				if o.Format != formatRuns {
+					t.Errorf("got format %q", o.Format)
+				}
+			},
+		},
+		{
+			name: "shorthand agreeing with format",
+			args: []string{"--format", "csv", "--csv", "--bom", "cli"},
+			check: func(t *testing.T, o *options) {
+				if o.Format != formatCSV || !o.BOM {
+					t.Errorf("got format %q, bom %v", o.Format, o.BOM)
+				}
+			},
+		},
+		{
+			name: "repos",
+			args: []string{"-r", "cli,other/go-gh", "cli"},
+			check: func(t *testing.T, o *options) {
+				if !reflect.DeepEqual(o.Repositories, []string{"cli", "other/go-gh"}) {
+					t.Errorf("got repositories %v", o.Repositories)
+				}
+			},
+		},
+		{
+			name: "theme",
+			args: []string{"--theme", themeColorblind, "cli"},
+			check: func(t *testing.T, o *options) {
+				if o.Colors != themes[themeColorblind] {
+					t.Errorf("got colors %v", o.Colors)
+				}
+			},
+		},
+		{
+			name: "color overrides theme",
+			args: []string{"--theme", themeLight, "--color-success", "33", "cli"},
+			check: func(t *testing.T, o *options) {
+				if o.Colors.Success != "33" || o.Colors.Failed != themes[themeLight].Failed {
+					t.Errorf("got colors %v", o.Colors)
+				}
+			},
+		},
+		{
+			name: "concurrency raises max concurrency",
+			args: []string{"-c", "64", "cli"},
+			check: func(t *testing.T, o *options) {
+				if o.Concurrency != 64 || o.MaxConcurrency != 64 {
+					t.Errorf("got concurrency %d, max %d", o.Concurrency, o.MaxConcurrency)
+				}
+			},
+		},
+		{
+			name: "top per repo",
+			args: []string{"--top", "2", "--top-per-repo", "cli"},
+			check: func(t *testing.T, o *options) {
+				if o.Top != 2 || !o.TopPerRepo {
+					t.Errorf("got top %d, per repo %v", o.Top, o.TopPerRepo)
+				}
+			},
+		},
+		{
+			name: "private only",
+			args: []string{"--private-only", "cli"},
+			check: func(t *testing.T, o *options) {
+				if o.Visibility != visibilityPrivate {
+					t.Errorf("got visibility %q", o.Visibility)
+				}
+			},
+		},
+		{
+			name: "workflow filters",
+			args: []string{"-w", "deploy-*", "--workflow-file", "ci.yml", "--workflow-id", "7", "cli"},
+			check: func(t *testing.T, o *options) {
+				if !reflect.DeepEqual(o.Workflows, []string{"deploy-*"}) || !reflect.DeepEqual(o.WorkflowFiles, []string{"ci.yml"}) || !reflect.DeepEqual(o.WorkflowIDs, []int{7}) {
+					t.Errorf("got workflows %v, files %v, ids %v", o.Workflows, o.WorkflowFiles, o.WorkflowIDs)
+				}
+			},
+		},
+		{
+			name: "version needs no owner",
+			args: []string{"--version"},
+			check: func(t *testing.T, o *options) {
+				if !o.ShowVersion {
+					t.Error("expected ShowVersion")
+				}
+			},
+		},
+
+		{name: "no owner", args: []string{}, wantErr: "need at least one argument"},
+		{name: "unknown flag", args: []string{"--nope", "cli"}, wantErr: "unknown flag"},
+		{name: "last without unit", args: []string{"--last", "30", "cli"}, wantErr: "report duration should be in"},
+		{name: "last with unknown unit", args: []string{"--last", "3x", "cli"}, wantErr: "report duration should be in"},
+		{name: "last of zero", args: []string{"--last", "0h", "cli"}, wantErr: "must be positive"},
+		{name: "negative last", args: []string{"--last", "-1d", "cli"}, wantErr: "must be positive, got '-1d'"},
+		{name: "bad since", args: []string{"--since", "yesterday", "cli"}, wantErr: "invalid --since"},
+		{name: "unknown format", args: []string{"--format", "yaml", "cli"}, wantErr: "unknown format 'yaml'"},
+		{name: "unknown sort", args: []string{"--sort", "size", "cli"}, wantErr: "unknown sort 'size'"},
+		{name: "unknown billable os", args: []string{"--billable-os", "beos", "cli"}, wantErr: "unknown billable-os 'beos'"},
+		{name: "unknown group by", args: []string{"--group-by", "owner", "cli"}, wantErr: "unknown group-by 'owner'"},
+		{name: "unknown elapsed format", args: []string{"--elapsed-format", "roman", "cli"}, wantErr: "unknown elapsed format 'roman'"},
+		{name: "unknown theme", args: []string{"--theme", "neon", "cli"}, wantErr: "unknown theme 'neon'"},
+		{name: "bad color", args: []string{"--color-fail", "reddish", "cli"}, wantErr: "--color-fail"},
+		{name: "zero max runs", args: []string{"--max-runs", "0", "cli"}, wantErr: "max-runs must be at least 1"},
+		{name: "negative limit", args: []string{"--limit", "-1", "cli"}, wantErr: "limit must not be negative"},
+		{name: "negative width", args: []string{"--width", "-1", "cli"}, wantErr: "width must not be negative"},
+		{name: "zero interval", args: []string{"--interval", "0s", "cli"}, wantErr: "interval must be greater than zero"},
+		{name: "negative max api calls", args: []string{"--max-api-calls", "-1", "cli"}, wantErr: "max-api-calls must not be negative"},
+		{name: "negative timeout", args: []string{"--timeout", "-1s", "cli"}, wantErr: "timeout must not be negative"},
+		{name: "negative retries", args: []string{"--retries", "-1", "cli"}, wantErr: "retries must not be negative"},
+		{name: "fail threshold above 100", args: []string{"--fail-threshold", "101", "cli"}, wantErr: "fail-threshold must be between 0 and 100"},
+		{name: "negative min runs", args: []string{"--min-runs", "-1", "cli"}, wantErr: "min-runs must not be negative"},
+		{name: "negative top", args: []string{"--top", "-1", "cli"}, wantErr: "top must not be negative"},
+		{name: "top per repo without top", args: []string{"--top-per-repo", "cli"}, wantErr: "top-per-repo needs --top"},
+		{name: "empty glyph", args: []string{"--glyph-success", "", "cli"}, wantErr: "glyphs cannot be empty"},
+		{name: "negative cache ttl", args: []string{"--cache-ttl", "-1s", "cli"}, wantErr: "cache-ttl must not be negative"},
+		{name: "slow above very slow", args: []string{"--slow-threshold", "10m", "--very-slow-threshold", "5m", "cli"}, wantErr: "slow-threshold must be greater than zero"},
+		{name: "unknown event", args: []string{"--event", "tweet", "cli"}, wantErr: "unknown event 'tweet'"},
+		{name: "bad repository", args: []string{"-r", "a/b/c", "cli"}, wantErr: "invalid repository 'a/b/c'"},
+		{name: "bad workflow pattern", args: []string{"-w", "[", "cli"}, wantErr: "invalid workflow pattern"},
+		{name: "min concurrency of zero", args: []string{"--min-concurrency", "0", "cli"}, wantErr: "concurrency must satisfy"},
+		{name: "concurrency above max", args: []string{"-c", "8", "--max-concurrency", "4", "cli"}, wantErr: "concurrency must satisfy"},
+
+		{name: "two format shorthands", args: []string{"--json", "--table", "cli"}, wantErr: "--table cannot be combined with another --format"},
+		{name: "shorthand against format", args: []string{"--format", "table", "--csv", "cli"}, wantErr: "--csv cannot be combined with another --format"},
+		{name: "since and last", args: []string{"--since", "2024-01-01", "--last", "7d", "cli"}, wantErr: "--since and --last cannot be combined"},
+		{name: "default branch and branch", args: []string{"--default-branch-only", "--branch", "main", "cli"}, wantErr: "--default-branch-only and --branch cannot be combined"},
+		{name: "watch and stream", args: []string{"--watch", "--stream", "cli"}, wantErr: "--watch and --stream cannot be combined"},
+		{name: "watch and output", args: []string{"--watch", "-o", "out.txt", "cli"}, wantErr: "cannot be combined with --output"},
+		{name: "private and public", args: []string{"--private-only", "--public-only", "cli"}, wantErr: "--private-only and --public-only cannot be combined"},
+		{name: "bom without csv", args: []string{"--bom", "cli"}, wantErr: "--bom only applies to --format csv"},
+		{name: "group by workflow with json", args: []string{"--group-by", "workflow", "--json", "cli"}, wantErr: "--group-by workflow only applies"},
+		{name: "group by workflow with repo cards", args: []string{"--group-by", "workflow", "--repo-cards", "cli"}, wantErr: "--group-by workflow only applies"},
+		{name: "otlp without endpoint", args: []string{"--format", "otlp", "cli"}, wantErr: "--format otlp requires --otlp-endpoint"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+
+			opts, err := parseArgs(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			tt.check(t, opts)
+		})
+	}
+}
+
+func TestParseLast(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr string
+	}{
+		{value: "1h", want: time.Hour},
+		{value: "30d", want: 30 * 24 * time.Hour},
+		{value: "1w", want: 7 * 24 * time.Hour},
+		{value: "4w", want: 28 * 24 * time.Hour},
+		{value: "2mo", want: 60 * 24 * time.Hour},
+		{value: "3x", wantErr: "report duration should be in"},
+		{value: "d", wantErr: "could not parse number"},
+		{value: "0h", wantErr: "must be positive, got '0h'"},
+		{value: "0d", wantErr: "must be positive, got '0d'"},
+		{value: "0w", wantErr: "must be positive, got '0w'"},
+		{value: "0mo", wantErr: "must be positive, got '0mo'"},
+		{value: "-2h", wantErr: "must be positive, got '-2h'"},
+		{value: "-1d", wantErr: "must be positive, got '-1d'"},
+		{value: "-1w", wantErr: "must be positive, got '-1w'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseLast(tt.value)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got %s and error %v, want an error containing %q", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunCLIExitCodes(t *testing.T) {
+	noFetcher := func(string) (Fetcher, error) {
+		return nil, errors.New("no API in tests")
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		want       int
+		wantStdout string
+		wantStderr string
+	}{
+		{name: "version", args: []string{"--version"}, want: 0, wantStdout: "actions-dashboard "},
+		{name: "help", args: []string{"--help"}, want: 0},
+		{name: "bad arguments", args: []string{"--last", "0h", "cli"}, want: 1, wantStderr: "failed to parse arguments: report duration must be positive"},
+		{name: "dry run makes no calls", args: []string{"--dry-run", "-r", "cli", "cli"}, want: 0, wantStderr: "repos/cli/cli"},
+		{name: "no API client", args: []string{"cli"}, want: 1, wantStderr: "no API in tests"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+
+			var stdout, stderr bytes.Buffer
+			got := runCLI(tt.args, &stdout, &stderr, noFetcher)
+			if got != tt.want {
+				t.Errorf("got exit code %d, want %d; stderr: %s", got, tt.want, stderr.String())
+			}
+			if !strings.Contains(stdout.String(), tt.wantStdout) {
+				t.Errorf("stdout %q doesn't contain %q", stdout.String(), tt.wantStdout)
+			}
+			if !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("stderr %q doesn't contain %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
+
+// runsTaking makes a completed, successful run per elapsed time, newest
+// first.
+func runsTaking(elapsed ...time.Duration) []run {
+	runs := []run{}
+	for _, e := range elapsed {
+		runs = append(runs, run{Status: "completed", Conclusion: "success", Elapsed: e})
+	}
+	return runs
+}
+
+func TestAverageElapsed(t *testing.T) {
+	tests := []struct {
+		name string
+		runs []run
+		want time.Duration
+	}{
+		{name: "no runs", runs: nil, want: 0},
+		{name: "one run", runs: runsTaking(90 * time.Second), want: 90 * time.Second},
+		{name: "two runs", runs: runsTaking(time.Minute, 3*time.Minute), want: 2 * time.Minute},
+		{
+			name: "five runs",
+			runs: runsTaking(time.Minute, 2*time.Minute, 3*time.Minute, 4*time.Minute, 5*time.Minute),
+			want: 3 * time.Minute,
+		},
+		{
+			name: "more than five runs",
+			runs: runsTaking(time.Minute, 2*time.Minute, 3*time.Minute, 4*time.Minute, 5*time.Minute, time.Hour),
+			want: 3 * time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &workflow{Runs: tt.runs}
+			if got := w.AverageElapsed(); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHealthAndAverageCoverMaxRuns(t *testing.T) {
+	sevenRuns := runsTaking(time.Minute, 2*time.Minute, 3*time.Minute, 4*time.Minute, 5*time.Minute, time.Hour, time.Hour)
+
+	tests := []struct {
+		name        string
+		maxRuns     int
+		wantGlyphs  int
+		wantAverage time.Duration
+	}{
+		{name: "default", maxRuns: 0, wantGlyphs: 5, wantAverage: 3 * time.Minute},
+		{name: "max runs of five", maxRuns: 5, wantGlyphs: 5, wantAverage: 3 * time.Minute},
+		{name: "max runs of two", maxRuns: 2, wantGlyphs: 2, wantAverage: 90 * time.Second},
+		{name: "max runs above the runs there are", maxRuns: 10, wantGlyphs: 7, wantAverage: 1157 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &workflow{Runs: sevenRuns, MaxRuns: tt.maxRuns}
+
+			health := w.RenderHealth(renderOptions{})
+			if got := strings.Count(health, glyphs.Success); got != tt.wantGlyphs {
+				t.Errorf("got %d glyphs in %q, want %d", got, health, tt.wantGlyphs)
+			}
+			if got := w.AverageElapsed(); got != tt.wantAverage {
+				t.Errorf("got average %s, want %s", got, tt.wantAverage)
+			}
+		})
+	}
+}
+
+func TestFormatElapsed(t *testing.T) {
+	tests := []struct {
+		elapsed time.Duration
+		format  string
+		want    string
+	}{
+		{elapsed: 400 * time.Millisecond, format: elapsedFormatGo, want: "400ms"},
+		{elapsed: 90 * time.Second, format: elapsedFormatGo, want: "1m30s"},
+		{elapsed: 2*time.Hour + 5*time.Minute, format: elapsedFormatGo, want: "2h5m0s"},
+		{elapsed: 400 * time.Millisecond, format: elapsedFormatClock, want: "00:00"},
+		{elapsed: 90 * time.Second, format: elapsedFormatClock, want: "01:30"},
+		{elapsed: 2*time.Hour + 5*time.Minute, format: elapsedFormatClock, want: "2:05:00"},
+		{elapsed: 400 * time.Millisecond, format: elapsedFormatHuman, want: "400 ms"},
+		{elapsed: 90 * time.Second, format: elapsedFormatHuman, want: "1 min 30 sec"},
+		{elapsed: 2*time.Hour + 5*time.Minute, format: elapsedFormatHuman, want: "2 hr 5 min"},
+	}
+
+	for _, tt := range tests {
+		if got := formatElapsed(tt.elapsed, tt.format); got != tt.want {
+			t.Errorf("formatElapsed(%s, %s) = %q, want %q", tt.elapsed, tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestTruncateWorkflowName(t *testing.T) {
+	tests := []struct {
+		name   string
+		length int
+		want   string
+	}{
+		{name: "CI", length: 17, want: "CI"},
+		{name: "exactly seventeen", length: 17, want: "exactly seventeen"},
+		{name: "Build and deploy to production", length: 17, want: "Build and deploy ..."},
+		{name: "🚀 Deploy rockets", length: 17, want: "🚀 Deploy rockets"},
+		{name: "🚀🚀🚀 Deploy rockets", length: 10, want: "🚀🚀🚀 Dep..."},
+		{name: "Déploiement en production", length: 11, want: "Déploiement..."},
+		{name: "Café nightly build", length: 4, want: "Café..."},
+		{name: "持续集成", length: 8, want: "持续集成"},
+		{name: "持续集成和部署", length: 5, want: "持续..."},
+	}
+
+	for _, tt := range tests {
+		got := truncateWorkflowName(tt.name, tt.length)
+		if got != tt.want {
+			t.Errorf("truncateWorkflowName(%q, %d) = %q, want %q", tt.name, tt.length, got, tt.want)
+		}
+
+		truncated := runewidth.StringWidth(tt.name) > tt.length
+		if strings.HasSuffix(got, "...") != truncated {
+			t.Errorf("%q: got %q, want an ellipsis only when truncated", tt.name, got)
+		}
+		kept := strings.TrimSuffix(got, "...")
+		if !utf8.ValidString(got) || !strings.HasPrefix(tt.name, kept) {
+			t.Errorf("%q: got %q, which doesn't end on a rune boundary", tt.name, got)
+		}
+		if w := runewidth.StringWidth(kept); w > tt.length {
+			t.Errorf("%q: got %q, %d columns wide, want at most %d plus the ellipsis", tt.name, got, w, tt.length)
+		}
+	}
+}
+
+func TestLastRun(t *testing.T) {
+	older := finishedRun("cli/cli", "success", "2024-05-01T12:00:00Z", time.Minute, 0)
+	newer := finishedRun("cli/cli", "failure", "2024-05-03T12:00:00Z", time.Minute, 0)
+
+	tests := []struct {
+		name string
+		runs []run
+		want time.Time
+	}{
+		{name: "no runs"},
+		{name: "newest first", runs: []run{newer, older}, want: newer.Finished},
+		{name: "out of order", runs: []run{older, newer}, want: newer.Finished},
+	}
+
+	for _, tt := range tests {
+		w := &workflow{Name: "CI", Runs: tt.runs}
+		if got := w.LastRun(); !got.Equal(tt.want) {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCardLastRun(t *testing.T) {
+	tests := []struct {
+		ago  time.Duration
+		want string
+	}{
+		{ago: 5 * time.Minute, want: "Last run: 5m ago"},
+		{ago: 3*time.Hour + 10*time.Minute, want: "Last run: 3h ago"},
+		{ago: 300 * 24 * time.Hour, want: "Last run: 10mo ago"},
+	}
+
+	c := newCardRenderer(&bytes.Buffer{}, testOptions(t, "cli"))
+	for _, tt := range tests {
+		r := run{Status: "completed", Conclusion: "success", Finished: time.Now().Add(-tt.ago), Elapsed: time.Minute}
+		w := &workflow{Name: "CI", Runs: []run{r}}
+
+		// Rendered in a card, the line has to fit without wrapping.
+		card := c.cardStyle.Render(w.RenderCard(c.render))
+		if !strings.Contains(card, tt.want) {
+			t.Errorf("card doesn't contain %q:\n%s", tt.want, card)
+		}
+	}
+
+	empty := (&workflow{Name: "CI"}).RenderCard(renderOptions{})
+	if strings.Contains(empty, "Last run") || !strings.Contains(empty, "No runs") {
+		t.Errorf("got card for a workflow without runs:\n%s", empty)
+	}
+}
+
+func TestSuccessRate(t *testing.T) {
+	runs := func(conclusions ...string) []run {
+		out := []run{}
+		for _, c := range conclusions {
+			if c == "in_progress" {
+				out = append(out, run{Status: c})
+				continue
+			}
+			out = append(out, run{Status: "completed", Conclusion: c})
+		}
+		return out
+	}
+
+	tests := []struct {
+		name             string
+		runs             []run
+		successes, total int
+		pct              float64
+	}{
+		{name: "no runs"},
+		{name: "all succeeded", runs: runs("success", "success"), successes: 2, total: 2, pct: 100},
+		{name: "mixed", runs: runs("success", "failure", "success", "timed_out"), successes: 2, total: 4, pct: 50},
+		{name: "skipped and cancelled left out", runs: runs("success", "skipped", "cancelled", "neutral", "failure", "success", "success"), successes: 3, total: 4, pct: 75},
+		{name: "unfinished left out", runs: runs("in_progress", "failure"), successes: 0, total: 1, pct: 0},
+		{name: "only skipped", runs: runs("skipped", "cancelled")},
+	}
+
+	for _, tt := range tests {
+		w := &workflow{Runs: tt.runs}
+		successes, total, pct := w.SuccessRate()
+		if successes != tt.successes || total != tt.total || pct != tt.pct {
+			t.Errorf("%s: got %d/%d (%g%%), want %d/%d (%g%%)", tt.name, successes, total, pct, tt.successes, tt.total, tt.pct)
+		}
+	}
+}
+
+func TestSortWorkflows(t *testing.T) {
+	// Each workflow has three runs of the same length, failures first.
+	makeWorkflow := func(name string, elapsed time.Duration, failures, billableMs int) *workflow {
+		runs := runsTaking(elapsed, elapsed, elapsed)
+		for i := 0; i < failures; i++ {
+			runs[i].Conclusion = "failure"
+		}
+		return &workflow{Name: name, Runs: runs, BillableMs: billableMs}
+	}
+
+	tests := []struct {
+		key     string
+		reverse bool
+		want    string
+	}{
+		{key: sortNone, want: "beta Alpha gamma delta"},
+		{key: sortNone, reverse: true, want: "beta Alpha gamma delta"},
+		{key: sortName, want: "Alpha beta delta gamma"},
+		{key: sortName, reverse: true, want: "gamma delta beta Alpha"},
+		// beta and gamma tie, and stay in name order either way.
+		{key: sortElapsed, want: "delta beta gamma Alpha"},
+		{key: sortElapsed, reverse: true, want: "Alpha beta gamma delta"},
+		{key: sortHealth, want: "Alpha beta delta gamma"},
+		{key: sortHealth, reverse: true, want: "gamma beta delta Alpha"},
+		{key: sortBillable, want: "delta beta gamma Alpha"},
+		{key: sortBillable, reverse: true, want: "Alpha beta gamma delta"},
+	}
+
+	for _, tt := range tests {
+		workflows := []*workflow{
+			makeWorkflow("beta", 2*time.Minute, 1, 100),
+			makeWorkflow("Alpha", 5*time.Minute, 0, 300),
+			makeWorkflow("gamma", 2*time.Minute, 2, 100),
+			makeWorkflow("delta", time.Minute, 1, 0),
+		}
+		sortWorkflows(workflows, tt.key, tt.reverse)
+
+		names := []string{}
+		for _, w := range workflows {
+			names = append(names, w.Name)
+		}
+		if got := strings.Join(names, " "); got != tt.want {
+			t.Errorf("--sort %s (reverse %t): got %s, want %s", tt.key, tt.reverse, got, tt.want)
+		}
+	}
+}
+
+func TestCheckSuccessRates(t *testing.T) {
+	// succeeding makes a workflow that succeeded in successes out of total
+	// runs.
+	succeeding := func(name string, successes, total int) *workflow {
+		runs := []run{}
+		for i := 0; i < total; i++ {
+			conclusion := "failure"
+			if i < successes {
+				conclusion = "success"
+			}
+			runs = append(runs, run{Status: "completed", Conclusion: conclusion})
+		}
+		return &workflow{Name: name, Runs: runs}
+	}
+	repos := []*repositoryData{
+		{Name: "cli/a", Workflows: []*workflow{
+			succeeding("Perfect", 4, 4),
+			succeeding("Mostly", 9, 10),
+			succeeding("Idle", 0, 0),
+		}},
+		{Name: "cli/b", Workflows: []*workflow{
+			succeeding("Flaky", 1, 2),
+		}},
+	}
+
+	tests := []struct {
+		threshold float64
+		want      string
+	}{
+		{threshold: 50},
+		{threshold: 51, want: "1 workflow below 51% success:\n  cli/b: Flaky (50%)"},
+		{threshold: 90, want: "1 workflow below 90% success:\n  cli/b: Flaky (50%)"},
+		{threshold: 90.5, want: "2 workflows below 90.5% success:\n  cli/a: Mostly (90%)\n  cli/b: Flaky (50%)"},
+		// Only a workflow that never failed passes at 100%.
+		{threshold: 100, want: "2 workflows below 100% success:\n  cli/a: Mostly (90%)\n  cli/b: Flaky (50%)"},
+	}
+
+	for _, tt := range tests {
+		err := checkSuccessRates(repos, tt.threshold)
+		if tt.want == "" {
+			if err != nil {
+				t.Errorf("threshold %g: got %v, want no error", tt.threshold, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("threshold %g: got %v, want %q", tt.threshold, err, tt.want)
+		}
+	}
+}
+
+func TestRenderDurationSparkline(t *testing.T) {
+	tests := []struct {
+		name    string
+		elapsed []time.Duration
+		maxRuns int
+		want    string
+	}{
+		{name: "no runs", want: ""},
+		{name: "single run", elapsed: []time.Duration{time.Minute}, want: "▄"},
+		{name: "all equal", elapsed: []time.Duration{time.Minute, time.Minute, time.Minute}, want: "▄▄▄"},
+		{name: "fastest to slowest", elapsed: []time.Duration{0, time.Minute, 2 * time.Minute, 7 * time.Minute}, want: "▁▂▃█"},
+		{name: "in run order", elapsed: []time.Duration{10 * time.Minute, 3 * time.Minute, 3*time.Minute + 30*time.Second}, want: "█▁▁"},
+		{
+			name:    "scaled over the health strip only",
+			elapsed: []time.Duration{time.Minute, 2 * time.Minute, time.Hour},
+			maxRuns: 2,
+			want:    "▁█",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &workflow{Runs: runsTaking(tt.elapsed...), MaxRuns: tt.maxRuns}
+			if got := w.RenderDurationSparkline(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPercentileElapsed(t *testing.T) {
+	tests := []struct {
+		name    string
+		elapsed []time.Duration
+		p       float64
+		want    time.Duration
+	}{
+		{name: "no runs", p: 50, want: 0},
+		{name: "single run p50", elapsed: []time.Duration{time.Minute}, p: 50, want: time.Minute},
+		{name: "single run p95", elapsed: []time.Duration{time.Minute}, p: 95, want: time.Minute},
+		{name: "two runs p50", elapsed: []time.Duration{2 * time.Minute, time.Minute}, p: 50, want: 90 * time.Second},
+		{name: "two runs p95", elapsed: []time.Duration{time.Minute, 2 * time.Minute}, p: 95, want: 117 * time.Second},
+		{name: "three runs p50 is the median", elapsed: []time.Duration{time.Hour, time.Minute, 3 * time.Minute}, p: 50, want: 3 * time.Minute},
+		{name: "three runs p0", elapsed: []time.Duration{time.Hour, time.Minute, 3 * time.Minute}, p: 0, want: time.Minute},
+		{name: "three runs p100", elapsed: []time.Duration{time.Hour, time.Minute, 3 * time.Minute}, p: 100, want: time.Hour},
+		{name: "four runs p90", elapsed: []time.Duration{10 * time.Second, 20 * time.Second, 30 * time.Second, 40 * time.Second}, p: 90, want: 37 * time.Second},
+		{name: "rounded to the second", elapsed: []time.Duration{time.Second, 2 * time.Second}, p: 75, want: 2 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &workflow{Runs: runsTaking(tt.elapsed...)}
+			if got := w.PercentileElapsed(tt.p); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPercentileElapsedCoversMaxRuns(t *testing.T) {
+	w := &workflow{Runs: runsTaking(time.Minute, 2*time.Minute, time.Hour), MaxRuns: 2}
+	if got := w.PercentileElapsed(100); got != 2*time.Minute {
+		t.Errorf("got %s, want 2m0s from the runs in the health strip", got)
+	}
+}
+
+func TestConclusionCounts(t *testing.T) {
+	w := &workflow{Runs: []run{
+		{Status: "completed", Conclusion: "success"},
+		{Status: "completed", Conclusion: "success"},
+		{Status: "completed", Conclusion: "failure"},
+		{Status: "completed", Conclusion: "cancelled"},
+		{Status: "completed", Conclusion: "skipped"},
+		{Status: "completed", Conclusion: "timed_out"},
+		{Status: "completed", Conclusion: "action_required"},
+		{Status: "completed", Conclusion: "neutral"},
+		{Status: "completed", Conclusion: "startup_failure"},
+		{Status: "in_progress"},
+		{Status: "queued"},
+		{Status: "queued"},
+	}}
+
+	want := map[string]int{
+		"success":         2,
+		"failure":         1,
+		"cancelled":       1,
+		"skipped":         1,
+		"timed_out":       1,
+		"action_required": 1,
+		"neutral":         1,
+		"startup_failure": 1,
+		"in_progress":     1,
+		"queued":          2,
+	}
+	got := w.ConclusionCounts()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if _, ok := got[""]; ok {
+		t.Error("counted unfinished runs under an empty conclusion")
+	}
+
+	if got := (&workflow{}).ConclusionCounts(); len(got) != 0 {
+		t.Errorf("got %v for no runs", got)
+	}
+}
+
+func TestRenderConclusionCounts(t *testing.T) {
+	got := renderConclusionCounts(map[string]int{
+		"timed_out": 1,
+		"skipped":   3,
+		"queued":    2,
+		"success":   4,
+		"failure":   1,
+	})
+	if want := "4 success, 1 failure, 3 skipped, 2 queued, 1 timed_out"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRenderCompactHealth(t *testing.T) {
+	withColor(t, false)
+	old := glyphs
+	glyphs = defaultGlyphs
+	defer func() { glyphs = old }()
+
+	ok := run{Status: "completed", Conclusion: "success"}
+	bad := run{Status: "completed", Conclusion: "failure"}
+	skipped := run{Status: "completed", Conclusion: "skipped"}
+	running := run{Status: "in_progress"}
+
+	tests := []struct {
+		name string
+		runs []run
+		want string
+	}{
+		{name: "no runs", want: ""},
+		{name: "all identical", runs: []run{ok, ok, ok, ok, ok}, want: "✓×5"},
+		{name: "short streaks written out", runs: []run{ok, ok, bad, bad, ok}, want: "✓✓xx✓"},
+		{name: "mixed", runs: []run{ok, ok, ok, bad, ok, bad, bad, bad, bad}, want: "✓×3x✓x×4"},
+		{name: "neutral outcomes streak together", runs: []run{skipped, running, skipped, ok}, want: "-×3✓"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderCompactHealth(tt.runs); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderHealthEmphasizeLatest(t *testing.T) {
+	old, oldColors := glyphs, colors
+	glyphs, colors = defaultGlyphs, defaultPalette
+	defer func() { glyphs, colors = old, oldColors }()
+
+	ok := run{Status: "completed", Conclusion: "success"}
+	bad := run{Status: "completed", Conclusion: "failure"}
+	w := &workflow{Runs: []run{bad, ok, ok, ok, ok, ok}, MaxRuns: 5}
+
+	tests := []struct {
+		name  string
+		ro    renderOptions
+		color bool
+		want  string
+	}{
+		{name: "plain", ro: renderOptions{EmphasizeLatest: true}, want: "[x]✓✓✓✓"},
+		{name: "compact", ro: renderOptions{EmphasizeLatest: true, CompactHealth: true}, want: "[x]✓×4"},
+		{name: "off", ro: renderOptions{}, want: "x✓✓✓✓"},
+		{
+			name:  "bold in color",
+			ro:    renderOptions{EmphasizeLatest: true},
+			color: true,
+			want:  "\x1b[1;38;2;220;20;60m[x]\x1b[0m",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withColor(t, tt.color)
+			got := w.RenderHealth(tt.ro)
+			if tt.color {
+				if !strings.HasPrefix(got, tt.want) {
+					t.Errorf("got %q, want it to start with %q", got, tt.want)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if got := (&workflow{}).RenderHealth(renderOptions{EmphasizeLatest: true}); got != "" {
+		t.Errorf("got %q for no runs", got)
+	}
+}
+
+func TestMatchesWorkflow(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters []string
+		want    bool
+	}{
+		{name: "CI", want: true},
+		{name: "Deploy Production", filters: []string{"deploy"}, want: true},
+		{name: "Deploy Production", filters: []string{"DEPLOY PROD"}, want: true},
+		{name: "Deploy Production", filters: []string{"staging"}},
+		{name: "Nightly build", filters: []string{"staging", "build"}, want: true},
+		{name: "deploy-staging", filters: []string{"deploy-*"}, want: true},
+		{name: "Deploy-Prod", filters: []string{"deploy-*"}, want: true},
+		{name: "pre-deploy-check", filters: []string{"deploy-*"}},
+		{name: "deploy", filters: []string{"deploy-*"}},
+		{name: "deploy-eu", filters: []string{"deploy-??"}, want: true},
+		{name: "deploy-us-east", filters: []string{"deploy-??"}},
+		{name: "lint-a", filters: []string{"lint-[ab]"}, want: true},
+		{name: "lint-c", filters: []string{"lint-[ab]"}},
+		{name: "lint-c", filters: []string{"lint-[ab]", "lint"}, want: true},
+	}
+
+	for _, tt := range tests {
+		if got := matchesWorkflow(tt.name, tt.filters); got != tt.want {
+			t.Errorf("%q against %q: got %t, want %t", tt.name, tt.filters, got, tt.want)
+		}
+	}
+}
+
+func TestMatchesWorkflowFile(t *testing.T) {
+	ci := workflowsPayload{Id: 161335, Name: "CI", Path: ".github/workflows/ci.yml"}
+
+	tests := []struct {
+		name  string
+		ids   []int
+		files []string
+		want  bool
+	}{
+		{name: "no filters", want: true},
+		{name: "id", ids: []int{161335}, want: true},
+		{name: "other id", ids: []int{1}},
+		{name: "one of several ids", ids: []int{1, 161335}, want: true},
+		{name: "base name", files: []string{"ci.yml"}, want: true},
+		{name: "full path", files: []string{".github/workflows/ci.yml"}, want: true},
+		{name: "other file", files: []string{"release.yml"}},
+		{name: "partial name", files: []string{"ci"}},
+		{name: "other directory", files: []string{"other/ci.yml"}},
+		{name: "id or file", ids: []int{1}, files: []string{"ci.yml"}, want: true},
+		{name: "neither id nor file", ids: []int{1}, files: []string{"release.yml"}},
+	}
+
+	for _, tt := range tests {
+		if got := matchesWorkflowFile(ci, tt.ids, tt.files); got != tt.want {
+			t.Errorf("%s: got %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMatchesRepo(t *testing.T) {
+	tests := []struct {
+		repo     string
+		patterns []string
+		want     bool
+	}{
+		{repo: "cli/cli"},
+		{repo: "cli/cli", patterns: []string{"cli"}, want: true},
+		{repo: "cli/cli", patterns: []string{"CLI"}, want: true},
+		{repo: "cli/go-gh", patterns: []string{"cli"}},
+		{repo: "cli/go-gh", patterns: []string{"go"}},
+		{repo: "cli/go-gh", patterns: []string{"cli/go-gh"}, want: true},
+		{repo: "cli/go-gh", patterns: []string{"other/go-gh"}},
+		{repo: "cli/sandbox-1", patterns: []string{"sandbox-*"}, want: true},
+		{repo: "cli/my-sandbox-1", patterns: []string{"sandbox-*"}},
+		{repo: "cli/sandbox-1", patterns: []string{"cli/sandbox-*"}, want: true},
+		{repo: "other/sandbox-1", patterns: []string{"cli/sandbox-*"}},
+		{repo: "cli/sandbox-1", patterns: []string{"*/sandbox-?"}, want: true},
+		{repo: "cli/docs", patterns: []string{"sandbox-*", "docs"}, want: true},
+	}
+
+	for _, tt := range tests {
+		if got := matchesRepo(tt.repo, tt.patterns); got != tt.want {
+			t.Errorf("%s against %q: got %t, want %t", tt.repo, tt.patterns, got, tt.want)
+		}
+	}
+}
+
+func TestExcludeRepos(t *testing.T) {
+	repos := []*repositoryData{{Name: "cli/cli"}, {Name: "cli/sandbox-a"}, {Name: "cli/Sandbox-B"}, {Name: "other/sandbox-c"}}
+
+	tests := []struct {
+		patterns []string
+		want     string
+	}{
+		{want: "cli/cli cli/sandbox-a cli/Sandbox-B other/sandbox-c"},
+		{patterns: []string{"sandbox-*"}, want: "cli/cli"},
+		{patterns: []string{"cli/sandbox-*"}, want: "cli/cli other/sandbox-c"},
+		{patterns: []string{"cli/sandbox-a", "cli"}, want: "cli/Sandbox-B other/sandbox-c"},
+	}
+
+	for _, tt := range tests {
+		got := []string{}
+		for _, r := range excludeRepos(repos, tt.patterns) {
+			got = append(got, r.Name)
+		}
+		if strings.Join(got, " ") != tt.want {
+			t.Errorf("excluding %q: got %s, want %s", tt.patterns, strings.Join(got, " "), tt.want)
+		}
+	}
+}
+
+func TestFilterReposByVisibility(t *testing.T) {
+	repos := []*repositoryData{{Name: "cli/cli"}, {Name: "cli/internal", Private: true}, {Name: "cli/go-gh"}}
+
+	tests := []struct {
+		visibility string
+		want       string
+	}{
+		{want: "cli/cli cli/internal cli/go-gh"},
+		{visibility: visibilityPrivate, want: "cli/internal"},
+		{visibility: visibilityPublic, want: "cli/cli cli/go-gh"},
+	}
+
+	for _, tt := range tests {
+		got := []string{}
+		for _, r := range filterReposByVisibility(repos, tt.visibility) {
+			got = append(got, r.Name)
+		}
+		if strings.Join(got, " ") != tt.want {
+			t.Errorf("visibility %q: got %s, want %s", tt.visibility, strings.Join(got, " "), tt.want)
+		}
+	}
+}
+
+func TestReadReposFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{name: "empty", want: []string{}},
+		{name: "names and owner/names", content: "cli\nother/go-gh\n", want: []string{"cli", "other/go-gh"}},
+		{name: "no trailing newline", content: "cli\nother/go-gh", want: []string{"cli", "other/go-gh"}},
+		{
+			name:    "blank lines, comments and whitespace",
+			content: "# dashboards\n\n  cli  \n\tother/go-gh # the library\n#other/skipped\n   \n",
+			want:    []string{"cli", "other/go-gh"},
+		},
+		{name: "windows line endings", content: "cli\r\nother/go-gh\r\n", want: []string{"cli", "other/go-gh"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readReposFile(strings.NewReader(tt.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReposFileFlag(t *testing.T) {
+	isolate(t)
+	path := filepath.Join(t.TempDir(), "repos.txt")
+	if err := os.WriteFile(path, []byte("# curated\nother/go-gh\ncli\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	opts, err := parseArgs([]string{"-r", "docs", "--repos-file", path, "cli"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"docs", "other/go-gh", "cli"}; !reflect.DeepEqual(opts.Repositories, want) {
+		t.Errorf("got repositories %q, want --repos then the file's %q", opts.Repositories, want)
+	}
+
+	stdin, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdin.Close()
+	oldStdin := os.Stdin
+	os.Stdin = stdin
+	defer func() { os.Stdin = oldStdin }()
+
+	opts, err = parseArgs([]string{"--repos-file", "-", "cli"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"other/go-gh", "cli"}; !reflect.DeepEqual(opts.Repositories, want) {
+		t.Errorf("got repositories %q from stdin, want %q", opts.Repositories, want)
+	}
+
+	if _, err := parseArgs([]string{"--repos-file", filepath.Join(t.TempDir(), "missing.txt"), "cli"}); err == nil || !strings.Contains(err.Error(), "could not open repos file") {
+		t.Errorf("got %v for a missing file", err)
+	}
+
+	if err := os.WriteFile(path, []byte("cli/cli/extra\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parseArgs([]string{"--repos-file", path, "cli"}); err == nil || !strings.Contains(err.Error(), "invalid repository 'cli/cli/extra'") {
+		t.Errorf("got %v for an invalid entry", err)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "2024-01-01", want: time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)},
+		{value: "2024-02-29", want: time.Date(2024, 2, 29, 0, 0, 0, 0, time.Local)},
+		{value: "2024-05-06T12:30:00Z", want: time.Date(2024, 5, 6, 12, 30, 0, 0, time.UTC)},
+		{value: "2024-05-06T12:30:00+02:00", want: time.Date(2024, 5, 6, 10, 30, 0, 0, time.UTC)},
+		{value: "2023-02-29", wantErr: true},
+		{value: "2024-13-01", wantErr: true},
+		{value: "2024/01/01", wantErr: true},
+		{value: "2024-05-06 12:30:00", wantErr: true},
+		{value: "yesterday", wantErr: true},
+		{value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseSince(tt.value)
+		if tt.wantErr {
+			if err == nil || !strings.Contains(err.Error(), "invalid --since") {
+				t.Errorf("%q: got %s, %v; want an error", tt.value, got, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %s", tt.value, err)
+		} else if !got.Equal(tt.want) {
+			t.Errorf("%q: got %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestAverageQueueTime(t *testing.T) {
+	queued := func(d time.Duration) run {
+		return run{Status: "completed", Conclusion: "success", Queued: d, QueueKnown: true}
+	}
+	unknown := run{Status: "completed", Conclusion: "success"}
+
+	tests := []struct {
+		name    string
+		runs    []run
+		maxRuns int
+		want    time.Duration
+	}{
+		{name: "no runs"},
+		{name: "no start times", runs: []run{unknown, unknown}},
+		{name: "known", runs: []run{queued(10 * time.Second), queued(30 * time.Second)}, want: 20 * time.Second},
+		{name: "runs without start times left out", runs: []run{unknown, queued(time.Minute), unknown, queued(0)}, want: 30 * time.Second},
+		{name: "rounded to the second", runs: []run{queued(time.Second), queued(2 * time.Second)}, want: 2 * time.Second},
+		{
+			name:    "health strip only",
+			runs:    []run{queued(time.Second), queued(3 * time.Second), queued(time.Hour)},
+			maxRuns: 2,
+			want:    2 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &workflow{Runs: tt.runs, MaxRuns: tt.maxRuns}
+			if got := w.AverageQueueTime(); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCardQueueTime(t *testing.T) {
+	withColor(t, false)
+	w := &workflow{Name: "CI", Runs: []run{{Status: "completed", Conclusion: "success", Queued: 90 * time.Second, QueueKnown: true}}}
+
+	if card := w.RenderCard(renderOptions{ShowQueue: true}); !strings.Contains(card, "Avg queue time: 1m30s") {
+		t.Errorf("card doesn't show queue time:\n%s", card)
+	}
+	if card := w.RenderCard(renderOptions{}); strings.Contains(card, "queue") {
+		t.Errorf("card shows queue time without --show-queue:\n%s", card)
+	}
+}
+
+func TestCardDisabled(t *testing.T) {
+	withColor(t, false)
+
+	card := (&workflow{Name: "Nightly", Disabled: true}).RenderCard(renderOptions{})
+	if !strings.Contains(card, "Disabled") {
+		t.Errorf("card isn't labelled disabled:\n%s", card)
+	}
+	if card := (&workflow{Name: "CI", Runs: runsTaking(time.Minute)}).RenderCard(renderOptions{}); strings.Contains(card, "Disabled") {
+		t.Errorf("active workflow labelled disabled:\n%s", card)
+	}
+}
+
+func TestParseArgsEvent(t *testing.T) {
+	isolate(t)
+
+	for _, event := range validEvents {
+		opts, err := parseArgs([]string{"--event", event, "cli"})
+		if err != nil {
+			t.Errorf("%s: %s", event, err)
+		} else if opts.Event != event {
+			t.Errorf("got event %q, want %q", opts.Event, event)
+		}
+	}
+
+	for _, event := range []string{"tweet", "Push", "pull-request", "cron", " schedule"} {
+		_, err := parseArgs([]string{"--event", event, "cli"})
+		if err == nil || !strings.Contains(err.Error(), "unknown event '"+event+"'") || !strings.Contains(err.Error(), "pull_request") {
+			t.Errorf("%q: got %v, want an error listing the known events", event, err)
+		}
+	}
+}
+
+func TestGetTerminalWidth(t *testing.T) {
+	// Keep the test's own terminal, if any, out of it.
+	notATerminal, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer notATerminal.Close()
+	oldStdout := os.Stdout
+	os.Stdout = notATerminal
+	defer func() { os.Stdout = oldStdout }()
+
+	tests := []struct {
+		name     string
+		override int
+		columns  string
+		want     int
+	}{
+		{name: "default", want: defaultTerminalWidth},
+		{name: "COLUMNS", columns: "100", want: 100},
+		{name: "--width over COLUMNS", override: 60, columns: "100", want: 60},
+		{name: "--width", override: 1, want: 1},
+		{name: "COLUMNS not a number", columns: "wide", want: defaultTerminalWidth},
+		{name: "COLUMNS zero", columns: "0", want: defaultTerminalWidth},
+		{name: "COLUMNS negative", columns: "-80", want: defaultTerminalWidth},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("COLUMNS", tt.columns)
+			if got := getTerminalWidth(tt.override); got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
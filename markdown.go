@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/vilmibm/actions-dashboard/util"
+)
+
+// markdownGlyph is runGlyph as an emoji, which renders in color on GitHub.
+func markdownGlyph(r run) string {
+	switch runOutcome(r) {
+	case outcomeSuccess:
+		return "✅"
+	case outcomeFailed:
+		return "❌"
+	default:
+		return "⬜"
+	}
+}
+
+// markdownCell escapes characters that would break out of a table cell.
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// renderMarkdown prints a heading and a table per repository, with no
+// terminal styling, for pasting into issues and pull requests.
+func renderMarkdown(out io.Writer, repos []*repositoryData, opts *options, asOf dataFreshness) error {
+	fmt.Fprintf(out, "# GitHub Actions dashboard for %s %s\n", opts.owners(), opts.period())
+	fmt.Fprintf(out, "\n_%s_\n", asOf.String())
+
+	for _, r := range repos {
+		if len(r.Workflows) == 0 && !opts.ShowEmpty {
+			continue
+		}
+
+		fmt.Fprintf(out, "\n## [%s](%s)\n\n", r.Name, actionsURL(r.Name))
+		if len(r.Workflows) == 0 {
+			fmt.Fprintln(out, "_No workflows_")
+			continue
+		}
+
+		fmt.Fprintln(out, "| Workflow | Health | Avg elapsed | Success rate | Billable |")
+		fmt.Fprintln(out, "| --- | --- | --- | --- | --- |")
+
+		for _, w := range r.Workflows {
+			health := ""
+			for i, rr := range w.Runs {
+				if i >= w.maxRuns() {
+					break
+				}
+				health += markdownGlyph(rr)
+			}
+			if len(w.Runs) == 0 && len(w.InProgress) > 0 {
+				health = fmt.Sprintf("%d running", len(w.InProgress))
+			}
+
+			success := "-"
+			if successes, total, pct := w.SuccessRate(); total > 0 {
+				success = fmt.Sprintf("%d/%d (%.0f%%)", successes, total, pct)
+			}
+
+			billable := "-"
+			if w.BillableMs > 0 {
+				billable = util.PrettyMS(w.BillableMs)
+			}
+
+			fmt.Fprintf(out, "| %s | %s | %s | %s | %s |\n",
+				markdownCell(w.Name), health, w.AverageElapsed(), success, billable)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMarkdownGlyph(t *testing.T) {
+	tests := []struct {
+		status, conclusion string
+		want               string
+	}{
+		{status: "completed", conclusion: "success", want: "✅"},
+		{status: "completed", conclusion: "failure", want: "❌"},
+		{status: "completed", conclusion: "timed_out", want: "❌"},
+		{status: "completed", conclusion: "cancelled", want: "⬜"},
+		{status: "completed", conclusion: "skipped", want: "⬜"},
+		{status: "in_progress", want: "⬜"},
+	}
+
+	for _, tt := range tests {
+		if got := markdownGlyph(run{Status: tt.status, Conclusion: tt.conclusion}); got != tt.want {
+			t.Errorf("%s/%s: got %s, want %s", tt.status, tt.conclusion, got, tt.want)
+		}
+	}
+}
+
+func TestRenderMarkdownGolden(t *testing.T) {
+	opts := testOptions(t, "--format", "markdown", "--show-empty", "cli")
+	asOf := dataFreshness{FetchedAt: time.Date(2024, 5, 6, 13, 0, 0, 0, time.UTC)}
+
+	var buf bytes.Buffer
+	if err := renderMarkdown(&buf, goldenDashboard(), opts, asOf); err != nil {
+		t.Fatal(err)
+	}
+
+	checkGolden(t, "dashboard.golden.md", buf.Bytes())
+}
+
+func TestRenderMarkdownTable(t *testing.T) {
+	opts := testOptions(t, "--format", "markdown", "cli")
+	repos := []*repositoryData{
+		{Name: "cli/cli", Workflows: []*workflow{
+			{Name: "Lint | Test", Runs: runsTaking(time.Minute, time.Minute), MaxRuns: 5},
+			{Name: "Deploy", Runs: []run{}, InProgress: []run{{Status: "in_progress"}, {Status: "queued"}}, MaxRuns: 5},
+		}},
+		{Name: "cli/empty", Workflows: []*workflow{}},
+	}
+
+	var buf bytes.Buffer
+	if err := renderMarkdown(&buf, repos, opts, dataFreshness{}); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	if strings.Contains(out, "cli/empty") {
+		t.Errorf("listed a repository without workflows without --show-empty:\n%s", out)
+	}
+
+	var rows []string
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "|") {
+			rows = append(rows, line)
+		}
+	}
+	want := []string{
+		"| Workflow | Health | Avg elapsed | Success rate | Billable |",
+		"| --- | --- | --- | --- | --- |",
+		`| Lint \| Test | ✅✅ | 1m0s | 2/2 (100%) | - |`,
+		"| Deploy | 2 running | 0s | - | - |",
+	}
+	if strings.Join(rows, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got rows\n%s\nwant\n%s", strings.Join(rows, "\n"), strings.Join(want, "\n"))
+	}
+
+	// Every row has the header's cells once escaped pipes are set aside.
+	for _, row := range rows {
+		if got := strings.Count(strings.ReplaceAll(row, `\|`, ""), "|"); got != 6 {
+			t.Errorf("row has %d cell borders, want 6: %s", got, row)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+// workflowGauges holds the point-in-time numbers exported for a single
+// workflow; buildOTLPPayload turns each into a data point of its own metric.
+type workflowGauges struct {
+	Repo              string
+	Workflow          string
+	SuccessRate       *float64 // nil when no runs have completed
+	AvgElapsedSeconds float64
+	BillableMs        int
+}
+
+func computeGauges(repos []*repositoryData) []workflowGauges {
+	out := []workflowGauges{}
+
+	for _, r := range repos {
+		for _, w := range r.Workflows {
+			g := workflowGauges{
+				Repo:              r.Name,
+				Workflow:          w.Name,
+				AvgElapsedSeconds: w.AverageElapsed().Seconds(),
+				BillableMs:        w.BillableMs,
+			}
+			// A rate of 0 would read as every run failing, so there is no
+			// data point at all until a run completes.
+			if _, total, pct := w.SuccessRate(); total > 0 {
+				rate := pct / 100
+				g.SuccessRate = &rate
+			}
+			out = append(out, g)
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The types below are the subset of the OTLP/HTTP JSON encoding needed to
+// push gauges; see opentelemetry-proto's metrics.proto for the full schema.
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpDataPoint struct {
+	Attributes   []otlpAttribute `json:"attributes"`
+	TimeUnixNano string          `json:"timeUnixNano"`
+	AsDouble     *float64        `json:"asDouble,omitempty"`
+	AsInt        string          `json:"asInt,omitempty"`
+}
+
+type otlpMetric struct {
+	Name  string `json:"name"`
+	Unit  string `json:"unit,omitempty"`
+	Gauge struct {
+		DataPoints []otlpDataPoint `json:"dataPoints"`
+	} `json:"gauge"`
+}
+
+type otlpScopeMetrics struct {
+	Scope struct {
+		Name string `json:"name"`
+	} `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	} `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpPayload struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+func otlpAttr(key, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpValue{StringValue: value}}
+}
+
+func buildOTLPPayload(gauges []workflowGauges, selector, host string, now time.Time) otlpPayload {
+	ts := strconv.FormatInt(now.UnixNano(), 10)
+
+	successRate := otlpMetric{Name: "success_rate", Unit: "1"}
+	avgElapsed := otlpMetric{Name: "avg_elapsed_seconds", Unit: "s"}
+	billable := otlpMetric{Name: "billable_ms", Unit: "ms"}
+
+	for _, g := range gauges {
+		attrs := []otlpAttribute{otlpAttr("repo", g.Repo), otlpAttr("workflow", g.Workflow)}
+		elapsed := g.AvgElapsedSeconds
+
+		if g.SuccessRate != nil {
+			successRate.Gauge.DataPoints = append(successRate.Gauge.DataPoints,
+				otlpDataPoint{Attributes: attrs, TimeUnixNano: ts, AsDouble: g.SuccessRate})
+		}
+		avgElapsed.Gauge.DataPoints = append(avgElapsed.Gauge.DataPoints,
+			otlpDataPoint{Attributes: attrs, TimeUnixNano: ts, AsDouble: &elapsed})
+		billable.Gauge.DataPoints = append(billable.Gauge.DataPoints,
+			otlpDataPoint{Attributes: attrs, TimeUnixNano: ts, AsInt: strconv.Itoa(g.BillableMs)})
+	}
+
+	scope := otlpScopeMetrics{Metrics: []otlpMetric{successRate, avgElapsed, billable}}
+	scope.Scope.Name = "github.com/vilmibm/actions-dashboard"
+
+	rm := otlpResourceMetrics{ScopeMetrics: []otlpScopeMetrics{scope}}
+	rm.Resource.Attributes = []otlpAttribute{
+		otlpAttr("service.name", "actions-dashboard"),
+		otlpAttr("selector", selector),
+		otlpAttr("host", host),
+	}
+
+	return otlpPayload{ResourceMetrics: []otlpResourceMetrics{rm}}
+}
+
+// exportOTLP pushes the dashboard's gauges to an OTLP/HTTP collector.
+func exportOTLP(out io.Writer, repos []*repositoryData, opts *options) error {
+	gauges := computeGauges(repos)
+	payload := buildOTLPPayload(gauges, opts.owners(), resolvedHost(), time.Now())
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("could not encode metrics: %w", err)
+	}
+
+	url := strings.TrimSuffix(opts.OTLPEndpoint, "/")
+	if !strings.HasSuffix(url, "/v1/metrics") {
+		url += "/v1/metrics"
+	}
+
+	client := http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("could not export metrics: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("could not export metrics: collector responded %s", resp.Status)
+	}
+
+	fmt.Fprintf(out, "Exported metrics for %d workflows to %s\n", len(gauges), url)
+
+	return nil
+}
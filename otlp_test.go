@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// otlpDashboard is cli/cli with a workflow that succeeded half the time and
+// one that hasn't run.
+func otlpDashboard() []*repositoryData {
+	return []*repositoryData{{
+		Name: "cli/cli",
+		Workflows: []*workflow{
+			{
+				Name: "CI",
+				Runs: []run{
+					{Status: "completed", Conclusion: "success", Elapsed: time.Minute},
+					{Status: "completed", Conclusion: "failure", Elapsed: 3 * time.Minute},
+				},
+				BillableMs: 1500,
+			},
+			{Name: "Nightly"},
+		},
+	}}
+}
+
+func TestComputeGauges(t *testing.T) {
+	gauges := computeGauges(otlpDashboard())
+	if len(gauges) != 2 {
+		t.Fatalf("got %d gauges, want 2", len(gauges))
+	}
+
+	ci, nightly := gauges[0], gauges[1]
+	if ci.Repo != "cli/cli" || ci.Workflow != "CI" || ci.AvgElapsedSeconds != 120 || ci.BillableMs != 1500 {
+		t.Errorf("got %+v", ci)
+	}
+	if ci.SuccessRate == nil || *ci.SuccessRate != 0.5 {
+		t.Errorf("got success rate %v, want 0.5", ci.SuccessRate)
+	}
+	if nightly.SuccessRate != nil {
+		t.Errorf("got success rate %v for a workflow without runs, want none", *nightly.SuccessRate)
+	}
+}
+
+func TestExportOTLP(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint string
+	}{
+		{name: "base URL", endpoint: ""},
+		{name: "trailing slash", endpoint: "/"},
+		{name: "full path", endpoint: "/v1/metrics"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var path, contentType string
+			var payload otlpPayload
+			collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				path, contentType = r.URL.Path, r.Header.Get("Content-Type")
+				body, _ := io.ReadAll(r.Body)
+				if err := json.Unmarshal(body, &payload); err != nil {
+					t.Errorf("collector got invalid JSON: %s", err)
+				}
+			}))
+			defer collector.Close()
+
+			old := apiHost
+			apiHost = "ghe.example.com"
+			defer func() { apiHost = old }()
+
+			opts := testOptions(t, "--format", "otlp", "--otlp-endpoint", collector.URL+tt.endpoint, "cli", "github")
+			var out bytes.Buffer
+			if err := exportOTLP(&out, otlpDashboard(), opts); err != nil {
+				t.Fatal(err)
+			}
+
+			if path != "/v1/metrics" || contentType != "application/json" {
+				t.Errorf("got POST to %s as %s", path, contentType)
+			}
+			if !strings.Contains(out.String(), "Exported metrics for 2 workflows to "+collector.URL+"/v1/metrics") {
+				t.Errorf("got output %q", out.String())
+			}
+
+			if len(payload.ResourceMetrics) != 1 {
+				t.Fatalf("got %d resources", len(payload.ResourceMetrics))
+			}
+			rm := payload.ResourceMetrics[0]
+			wantResource := map[string]string{"service.name": "actions-dashboard", "selector": "cli, github", "host": "ghe.example.com"}
+			if got := otlpAttrMap(rm.Resource.Attributes); !reflect.DeepEqual(got, wantResource) {
+				t.Errorf("got resource attributes %v, want %v", got, wantResource)
+			}
+
+			metrics := rm.ScopeMetrics[0].Metrics
+			want := []struct {
+				name, unit string
+				points     int
+			}{
+				// Nightly has no success rate yet.
+				{"success_rate", "1", 1},
+				{"avg_elapsed_seconds", "s", 2},
+				{"billable_ms", "ms", 2},
+			}
+			if len(metrics) != len(want) {
+				t.Fatalf("got %d metrics, want %d", len(metrics), len(want))
+			}
+			for i, m := range metrics {
+				if m.Name != want[i].name || m.Unit != want[i].unit || len(m.Gauge.DataPoints) != want[i].points {
+					t.Errorf("got metric %s (%s) with %d points, want %s (%s) with %d", m.Name, m.Unit, len(m.Gauge.DataPoints), want[i].name, want[i].unit, want[i].points)
+					continue
+				}
+				got := otlpAttrMap(m.Gauge.DataPoints[0].Attributes)
+				if !reflect.DeepEqual(got, map[string]string{"repo": "cli/cli", "workflow": "CI"}) {
+					t.Errorf("%s: got attributes %v", m.Name, got)
+				}
+			}
+
+			if p := metrics[0].Gauge.DataPoints[0]; p.AsDouble == nil || *p.AsDouble != 0.5 {
+				t.Errorf("got success rate point %+v", p)
+			}
+			if p := metrics[2].Gauge.DataPoints[0]; p.AsInt != "1500" {
+				t.Errorf("got billable point %+v", p)
+			}
+		})
+	}
+}
+
+func TestExportOTLPCollectorError(t *testing.T) {
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer collector.Close()
+
+	opts := testOptions(t, "--format", "otlp", "--otlp-endpoint", collector.URL, "cli")
+	err := exportOTLP(io.Discard, otlpDashboard(), opts)
+	if err == nil || !strings.Contains(err.Error(), "collector responded 503") {
+		t.Errorf("got %v", err)
+	}
+}
+
+func otlpAttrMap(attrs []otlpAttribute) map[string]string {
+	m := map[string]string{}
+	for _, a := range attrs {
+		m[a.Key] = a.Value.StringValue
+	}
+	return m
+}
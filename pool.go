@@ -0,0 +1,36 @@
+package main
+
+import (
+	"sync"
+)
+
+// runPool calls fn for every index below n on at most size goroutines and
+// waits for all of the calls to return. Every index is handed out even after
+// a failure; fn should pass its context to API calls so that, once the
+// context is cancelled, the remaining calls return straight away.
+func runPool(size, n int, fn func(i int)) {
+	if size < 1 {
+		size = 1
+	}
+	if size > n {
+		size = n
+	}
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < size; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				fn(i)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"golang.org/x/term"
+)
+
+// progressLine shows a transient status line on stderr while data is being
+// fetched. It is silent unless stderr is a terminal, so redirected output and
+// logs stay clean.
+type progressLine struct {
+	mu      sync.Mutex
+	out     io.Writer
+	enabled bool
+	message string
+
+	// Billable timing calls, counted across every workflow being fetched
+	// since they can outnumber every other call for private repositories.
+	timingsDone  int
+	timingsTotal int
+}
+
+// progress draws nothing unless stderr is a terminal.
+var progress = &progressLine{}
+
+func newProgressLine(f *os.File) *progressLine {
+	return &progressLine{out: f, enabled: term.IsTerminal(int(f.Fd()))}
+}
+
+// update replaces the status line with a new message.
+func (p *progressLine) update(format string, a ...interface{}) {
+	if !p.enabled {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.message = fmt.Sprintf(format, a...)
+	p.draw()
+}
+
+// addTimings counts n more timing calls to be made.
+func (p *progressLine) addTimings(n int) {
+	if !p.enabled {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.timingsTotal += n
+	p.draw()
+}
+
+// timingDone counts a finished timing call.
+func (p *progressLine) timingDone() {
+	if !p.enabled {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.timingsDone++
+	p.draw()
+}
+
+// resetTimings starts counting timing calls from zero, eg for a new fetch.
+func (p *progressLine) resetTimings() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.timingsDone, p.timingsTotal = 0, 0
+}
+
+// draw writes the message and any timing count; p.mu must be held. Nothing
+// is drawn after clear until the next update, so counts coming in from other
+// goroutines can't land in the middle of the dashboard.
+func (p *progressLine) draw() {
+	if p.message == "" {
+		return
+	}
+
+	line := p.message
+	if p.timingsTotal > 0 && p.timingsDone < p.timingsTotal {
+		line += fmt.Sprintf(" - fetching timings: %d/%d", p.timingsDone, p.timingsTotal)
+	}
+	fmt.Fprint(p.out, "\r\x1b[K"+line)
+}
+
+// clear removes the status line, eg before writing to a shared terminal.
+func (p *progressLine) clear() {
+	if !p.enabled {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.message = ""
+	fmt.Fprint(p.out, "\r\x1b[K")
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// frames splits what a progress line wrote into the lines it drew.
+func frames(out string) []string {
+	return strings.Split(strings.TrimPrefix(out, "\r\x1b[K"), "\r\x1b[K")
+}
+
+func TestProgressLineTimings(t *testing.T) {
+	var buf bytes.Buffer
+	p := &progressLine{out: &buf, enabled: true}
+
+	// Counts before there's a message aren't drawn.
+	p.addTimings(3)
+	if buf.Len() > 0 {
+		t.Fatalf("drew %q without a message", buf.String())
+	}
+
+	p.update("Fetching %s", "cli/a")
+	p.timingDone()
+	p.timingDone()
+	p.timingDone()
+	p.clear()
+	p.timingDone()
+
+	want := []string{
+		"Fetching cli/a - fetching timings: 0/3",
+		"Fetching cli/a - fetching timings: 1/3",
+		"Fetching cli/a - fetching timings: 2/3",
+		"Fetching cli/a",
+		"",
+	}
+	if got := frames(buf.String()); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("got frames %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	p.resetTimings()
+	p.update("Fetching %s", "cli/b")
+	p.addTimings(1)
+	if got, want := frames(buf.String()), []string{"Fetching cli/b", "Fetching cli/b - fetching timings: 0/1"}; strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("got frames %q after a reset, want %q", got, want)
+	}
+}
+
+func TestProgressLineDisabled(t *testing.T) {
+	var buf bytes.Buffer
+	p := &progressLine{out: &buf}
+
+	p.update("Fetching %s", "cli/a")
+	p.addTimings(2)
+	p.timingDone()
+	p.clear()
+
+	if buf.Len() > 0 {
+		t.Errorf("drew %q while disabled", buf.String())
+	}
+}
+
+func TestProgressLineCountsConcurrentTimings(t *testing.T) {
+	var buf bytes.Buffer
+	old := progress
+	progress = &progressLine{out: &buf, enabled: true}
+	defer func() { progress = old }()
+
+	f := newFakeFetcher()
+	runs := []run{}
+	for i := 0; i < 50; i++ {
+		runs = append(runs, run{URL: fmt.Sprintf("https://api.github.com/repos/cli/private/actions/runs/%d", i)})
+	}
+
+	progress.update("Fetching cli/private")
+	var wg sync.WaitGroup
+	for _, half := range [][]run{runs[:25], runs[25:]} {
+		wg.Add(1)
+		go func(runs []run) {
+			defer wg.Done()
+			if _, err := getRunTimings(context.Background(), f, runs, 8); err != nil {
+				t.Error(err)
+			}
+		}(half)
+	}
+	wg.Wait()
+
+	progress.mu.Lock()
+	done, total := progress.timingsDone, progress.timingsTotal
+	progress.mu.Unlock()
+	if done != 50 || total != 50 {
+		t.Errorf("counted %d/%d timings, want 50/50", done, total)
+	}
+	if got := frames(buf.String()); got[len(got)-1] != "Fetching cli/private" {
+		t.Errorf("last frame %q still counts timings", got[len(got)-1])
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/vilmibm/actions-dashboard/util"
+)
+
+const defaultReportTopN = 5
+
+// renderReport writes a plain text report suitable for circulating by email
+// or posting to a wiki.
+func renderReport(out io.Writer, repos []*repositoryData, opts *options) error {
+	var workflows, runs, billableMs int
+	for _, rw := range allWorkflows(repos) {
+		workflows++
+		runs += len(rw.Workflow.Runs)
+		billableMs += rw.Workflow.BillableMs
+	}
+
+	fmt.Fprintf(out, "GitHub Actions report for %s %s\n\n", opts.owners(), opts.period())
+	fmt.Fprintf(out, "Repositories: %d\n", len(repos))
+	fmt.Fprintf(out, "Workflows: %d\n", workflows)
+	fmt.Fprintf(out, "Runs: %d\n", runs)
+	fmt.Fprintf(out, "Total billable time: %s\n", util.PrettyMS(billableMs))
+
+	fmt.Fprintf(out, "\nNeeds attention\n\n")
+	fmt.Fprintf(out, "Failing:\n")
+	writeReportList(out, failingWorkflows(repos), func(rw repoWorkflow) string {
+		return rw.Workflow.Runs[0].Conclusion
+	})
+	fmt.Fprintf(out, "\nStale (no runs %s):\n", opts.period())
+	writeReportList(out, staleWorkflows(repos), nil)
+
+	fmt.Fprintf(out, "\nMost expensive\n\n")
+	writeReportList(out, topCostly(repos, defaultReportTopN), func(rw repoWorkflow) string {
+		return util.PrettyMS(rw.Workflow.BillableMs)
+	})
+
+	fmt.Fprintf(out, "\nSlowest\n\n")
+	writeReportList(out, topSlowest(repos, defaultReportTopN), func(rw repoWorkflow) string {
+		return fmt.Sprintf("%s avg", rw.Workflow.AverageElapsed())
+	})
+
+	return nil
+}
+
+func writeReportList(out io.Writer, items []repoWorkflow, detail func(repoWorkflow) string) {
+	if len(items) == 0 {
+		fmt.Fprintln(out, "- None")
+		return
+	}
+
+	for _, rw := range items {
+		if detail == nil {
+			fmt.Fprintf(out, "- %s: %s\n", rw.Repo, rw.Workflow.Name)
+			continue
+		}
+		fmt.Fprintf(out, "- %s: %s (%s)\n", rw.Repo, rw.Workflow.Name, detail(rw))
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"golang.org/x/term"
+)
+
+// linksEnabled reports whether OSC 8 hyperlinks can be written to out: it
+// must be a terminal, not a file or a pipe, and styling must be on.
+func linksEnabled(out io.Writer) bool {
+	f, ok := out.(*os.File)
+	return ok && colorEnabled && term.IsTerminal(int(f.Fd()))
+}
+
+// hyperlink makes text a link to url, for terminals that support OSC 8
+// hyperlinks, when links is set; otherwise it returns text alone. Terminals
+// without OSC 8 support print text alone too.
+func hyperlink(url, text string, links bool) string {
+	if !links || url == "" {
+		return text
+	}
+	return "\x1b]8;;" + url + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
+
+// runListRow describes a run for --list-runs: its glyph and number, how it
+// ended, how long it took, when it finished and its URL, as a link when links
+// is set.
+func runListRow(r run, ro renderOptions, links bool) []string {
+	number := "-"
+	if r.Number > 0 {
+		number = fmt.Sprintf("#%d", r.Number)
+	}
+
+	conclusion := r.Conclusion
+	finished := "running"
+	if r.Status != "completed" {
+		conclusion = r.Status
+	} else {
+		finished = r.Finished.Local().Format("Jan 2 15:04")
+	}
+
+	return []string{
+		renderRunGlyph(r),
+		number,
+		conclusion,
+		formatElapsed(r.Elapsed, ro.ElapsedFormat),
+		finished,
+		hyperlink(r.HTMLURL, r.HTMLURL, links),
+	}
+}
+
+// renderRunList prints each workflow's runs one per line instead of as a
+// health strip, for --list-runs, so that failures can be opened straight
+// from the terminal. It covers the same runs as the health strip.
+func renderRunList(out io.Writer, repos []*repositoryData, opts *options) error {
+	titleStyle := lipgloss.NewStyle().Bold(colorEnabled)
+	labelStyle := lipgloss.NewStyle().Foreground(colors.Label)
+	ro := opts.render()
+	links := linksEnabled(out)
+
+	if !opts.Quiet {
+		fmt.Fprintln(out, titleStyle.Render(fmt.Sprintf("GitHub Actions runs for %s %s", opts.owners(), opts.period())))
+	}
+
+	listed := 0
+	for _, r := range repos {
+		if len(r.Workflows) == 0 {
+			continue
+		}
+		if !opts.Quiet || listed > 0 {
+			fmt.Fprintln(out)
+		}
+		listed++
+		fmt.Fprintln(out, titleStyle.Render(r.Name))
+
+		for _, w := range r.Workflows {
+			fmt.Fprintln(out, labelStyle.Render(w.Name))
+
+			runs := w.Runs
+			if len(runs) > w.maxRuns() {
+				runs = runs[:w.maxRuns()]
+			}
+			if len(runs) == 0 {
+				fmt.Fprintln(out, "  no runs")
+				continue
+			}
+
+			rows := [][]string{}
+			for _, rr := range runs {
+				rows = append(rows, runListRow(rr, ro, links))
+			}
+
+			// The link is last so it needs no padding; lipgloss can't
+			// measure its escape sequences.
+			widths := make([]int, len(rows[0])-1)
+			for _, row := range rows {
+				for i, cell := range row[:len(widths)] {
+					if w := lipgloss.Width(cell); w > widths[i] {
+						widths[i] = w
+					}
+				}
+			}
+			total := 2 * (len(widths) - 1)
+			for _, w := range widths {
+				total += w
+			}
+			for _, row := range rows {
+				cells := formatTableRow(row[:len(widths)], widths)
+				cells += strings.Repeat(" ", total-lipgloss.Width(cells))
+				fmt.Fprintf(out, "  %s  %s\n", cells, row[len(widths)])
+			}
+		}
+	}
+
+	return nil
+}
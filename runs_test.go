@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHyperlink(t *testing.T) {
+	url := "https://github.com/cli/cli/actions/runs/1"
+
+	if got, want := hyperlink(url, "#1", true), "\x1b]8;;"+url+"\x1b\\#1\x1b]8;;\x1b\\"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := hyperlink(url, "#1", false); got != "#1" {
+		t.Errorf("got %q without links", got)
+	}
+	if got := hyperlink("", "#1", true); got != "#1" {
+		t.Errorf("got %q without a url", got)
+	}
+}
+
+func TestLinksEnabled(t *testing.T) {
+	withColor(t, true)
+
+	if linksEnabled(&bytes.Buffer{}) {
+		t.Error("links enabled for a buffer")
+	}
+
+	f, err := os.Create(filepath.Join(t.TempDir(), "runs.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if linksEnabled(f) {
+		t.Error("links enabled for a regular file")
+	}
+}
+
+func TestRunListRow(t *testing.T) {
+	withColor(t, false)
+	old := time.Local
+	time.Local = time.UTC
+	defer func() { time.Local = old }()
+
+	done := finishedRun("cli/cli", "failure", "2024-05-06T12:00:00Z", 90*time.Second, 0)
+	done.Number = 42
+	running := run{Status: "in_progress", Elapsed: time.Minute, HTMLURL: "https://github.com/cli/cli/actions/runs/43"}
+
+	tests := []struct {
+		name  string
+		r     run
+		links bool
+		want  []string
+	}{
+		{
+			name: "finished",
+			r:    done,
+			want: []string{"x", "#42", "failure", "1m30s", "May 6 12:00", done.HTMLURL},
+		},
+		{
+			name: "running without a number",
+			r:    running,
+			want: []string{"-", "-", "in_progress", "1m0s", "running", running.HTMLURL},
+		},
+		{
+			name:  "linked",
+			r:     done,
+			links: true,
+			want:  []string{"x", "#42", "failure", "1m30s", "May 6 12:00", hyperlink(done.HTMLURL, done.HTMLURL, true)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := runListRow(tt.r, renderOptions{}, tt.links)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderRunList(t *testing.T) {
+	opts := testOptions(t, "--list-runs", "cli")
+	withColor(t, true)
+
+	var buf bytes.Buffer
+	if err := renderRunList(&buf, goldenDashboard(), opts); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	if strings.Contains(out, "\x1b]8;") {
+		t.Errorf("wrote hyperlinks to a buffer:\n%q", out)
+	}
+	for _, want := range []string{
+		"https://github.com/cli/cli/actions/runs/0506120000\n",
+		"https://github.com/cli/internal/actions/runs/0505093000\n",
+		"success",
+		"failure",
+		"cancelled",
+		"no runs",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("run list doesn't contain %q:\n%s", want, out)
+		}
+	}
+	// The health strip's five runs, not the sixth that is outside it.
+	if strings.Contains(out, "runs/0501120000") {
+		t.Errorf("listed a run the health strip doesn't cover:\n%s", out)
+	}
+	if strings.Contains(out, "cli/empty") {
+		t.Errorf("listed a repository without workflows:\n%s", out)
+	}
+}
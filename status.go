@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	statusReasonFailing        = "failing"
+	statusReasonBelowThreshold = "below-threshold"
+)
+
+// statusSummary is what --status-json writes for CI: how many workflows are
+// in each state, and the exit code along with the checks that caused it.
+type statusSummary struct {
+	Workflows int      `json:"workflows"`
+	Healthy   int      `json:"healthy"`
+	Unhealthy int      `json:"unhealthy"`
+	Empty     int      `json:"empty"`
+	ExitCode  int      `json:"exit_code"`
+	Reasons   []string `json:"reasons"`
+}
+
+// buildStatusSummary counts every enabled workflow in repos as empty when it
+// has no runs in the window, unhealthy when its most recent run failed or its
+// success rate is below --fail-threshold, and healthy otherwise. Reasons only
+// name the checks that were asked for with --fail-on-error and
+// --fail-threshold, as only those change the exit code.
+func buildStatusSummary(repos []*repositoryData, opts *options) statusSummary {
+	s := statusSummary{Reasons: []string{}}
+	var failing, belowThreshold bool
+
+	for _, rw := range allWorkflows(repos) {
+		w := rw.Workflow
+		if w.Disabled {
+			continue
+		}
+		s.Workflows++
+
+		if len(w.Runs) == 0 {
+			s.Empty++
+			continue
+		}
+
+		latestFailed := w.Runs[0].failed()
+		_, total, pct := w.SuccessRate()
+		below := opts.FailThreshold > 0 && total > 0 && pct < opts.FailThreshold
+
+		if latestFailed || below {
+			s.Unhealthy++
+		} else {
+			s.Healthy++
+		}
+		failing = failing || latestFailed
+		belowThreshold = belowThreshold || below
+	}
+
+	if opts.FailOnError && failing {
+		s.Reasons = append(s.Reasons, statusReasonFailing)
+	}
+	if belowThreshold {
+		s.Reasons = append(s.Reasons, statusReasonBelowThreshold)
+	}
+	if len(s.Reasons) > 0 {
+		s.ExitCode = 1
+	}
+
+	return s
+}
+
+// writeStatusSummary writes s as a single line of JSON to path, or to stderr
+// when path is "-".
+func writeStatusSummary(stderr io.Writer, path string, s statusSummary) error {
+	out := stderr
+	if path != "-" {
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("could not open status file: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	return json.NewEncoder(out).Encode(s)
+}
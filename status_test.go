@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func statusDashboard() []*repositoryData {
+	ok := run{Status: "completed", Conclusion: "success"}
+	bad := run{Status: "completed", Conclusion: "failure"}
+
+	return []*repositoryData{
+		{Name: "cli/a", Workflows: []*workflow{
+			{Name: "Healthy", Runs: []run{ok, ok, ok}},
+			{Name: "Failing", Runs: []run{bad, ok, ok}},
+			// Passing now, but only half its runs succeeded.
+			{Name: "Flaky", Runs: []run{ok, bad}},
+		}},
+		{Name: "cli/b", Workflows: []*workflow{
+			{Name: "Empty", Runs: []run{}},
+			{Name: "Disabled", Disabled: true, Runs: []run{bad}},
+		}},
+	}
+}
+
+func TestBuildStatusSummary(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want statusSummary
+	}{
+		{
+			name: "no checks",
+			want: statusSummary{Workflows: 4, Healthy: 2, Unhealthy: 1, Empty: 1, Reasons: []string{}},
+		},
+		{
+			name: "fail on error",
+			args: []string{"--fail-on-error"},
+			want: statusSummary{Workflows: 4, Healthy: 2, Unhealthy: 1, Empty: 1, ExitCode: 1, Reasons: []string{statusReasonFailing}},
+		},
+		{
+			name: "threshold",
+			args: []string{"--fail-threshold", "60"},
+			want: statusSummary{Workflows: 4, Healthy: 1, Unhealthy: 2, Empty: 1, ExitCode: 1, Reasons: []string{statusReasonBelowThreshold}},
+		},
+		{
+			name: "threshold met",
+			args: []string{"--fail-threshold", "50"},
+			want: statusSummary{Workflows: 4, Healthy: 2, Unhealthy: 1, Empty: 1, Reasons: []string{}},
+		},
+		{
+			name: "both checks",
+			args: []string{"--fail-on-error", "--fail-threshold", "60"},
+			want: statusSummary{Workflows: 4, Healthy: 1, Unhealthy: 2, Empty: 1, ExitCode: 1, Reasons: []string{statusReasonFailing, statusReasonBelowThreshold}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := testOptions(t, append(tt.args, "cli")...)
+			if got := buildStatusSummary(statusDashboard(), opts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteStatusSummary(t *testing.T) {
+	s := statusSummary{Workflows: 2, Healthy: 1, Unhealthy: 1, ExitCode: 1, Reasons: []string{statusReasonFailing}}
+	want := `{"workflows":2,"healthy":1,"unhealthy":1,"empty":0,"exit_code":1,"reasons":["failing"]}` + "\n"
+
+	var stderr bytes.Buffer
+	if err := writeStatusSummary(&stderr, "-", s); err != nil {
+		t.Fatal(err)
+	}
+	if stderr.String() != want {
+		t.Errorf("got %q on stderr, want %q", stderr.String(), want)
+	}
+
+	path := filepath.Join(t.TempDir(), "status.json")
+	stderr.Reset()
+	if err := writeStatusSummary(&stderr, path, s); err != nil {
+		t.Fatal(err)
+	}
+	if stderr.Len() > 0 {
+		t.Errorf("wrote %q to stderr along with the file", stderr.String())
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got statusSummary
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, s) {
+		t.Errorf("got %+v, want %+v", got, s)
+	}
+
+	if err := writeStatusSummary(&stderr, filepath.Join(t.TempDir(), "missing", "status.json"), s); err == nil {
+		t.Error("wrote to a directory that doesn't exist")
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// workflowState describes a workflow by its most recent run, eg "success" or
+// "in_progress". Workflows without runs in the window are "none".
+func workflowState(w *workflow) string {
+	if len(w.Runs) == 0 {
+		return "none"
+	}
+
+	latest := w.Runs[0]
+	if latest.Status != "completed" {
+		return latest.Status
+	}
+
+	return latest.Conclusion
+}
+
+// diffStates reports a line for every workflow whose state differs from the
+// previous poll and records the new state in prev.
+func diffStates(prev map[string]string, repos []*repositoryData, now time.Time) []string {
+	lines := []string{}
+
+	for _, r := range repos {
+		for _, w := range r.Workflows {
+			key := fmt.Sprintf("%s/%s", r.Name, w.Name)
+			state := workflowState(w)
+			if old, ok := prev[key]; ok && old != state {
+				lines = append(lines, fmt.Sprintf("[%s] %s: %s → %s", now.Format("15:04"), key, old, state))
+			}
+			prev[key] = state
+		}
+	}
+
+	return lines
+}
+
+// streamChanges polls until interrupted, printing only workflow state
+// transitions so that the output stays compact in CI logs. An interrupt also
+// cancels a fetch in progress. Errors and warnings go to errOut.
+func streamChanges(out, errOut io.Writer, f Fetcher, opts *options) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	prev := map[string]string{}
+
+	for first := true; ; first = false {
+		fetchCtx, cancel := opts.fetchContext(ctx)
+		repos, err := fetchDashboard(fetchCtx, f, opts)
+		cancel()
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			fmt.Fprintf(errOut, "[%s] %s\n", time.Now().Format("15:04"), err)
+		} else {
+			warnings.flush(errOut)
+			for _, line := range diffStates(prev, repos, time.Now()) {
+				fmt.Fprintln(out, line)
+			}
+			if first {
+				fmt.Fprintf(out, "[%s] watching %d workflows for %s every %s\n", time.Now().Format("15:04"), len(prev), opts.owners(), opts.Interval)
+			}
+		}
+
+		select {
+		case <-time.After(opts.Interval):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWorkflowState(t *testing.T) {
+	tests := []struct {
+		name string
+		runs []run
+		want string
+	}{
+		{name: "no runs", want: "none"},
+		{name: "completed", runs: []run{{Status: "completed", Conclusion: "failure"}, {Status: "completed", Conclusion: "success"}}, want: "failure"},
+		{name: "running", runs: []run{{Status: "in_progress"}, {Status: "completed", Conclusion: "success"}}, want: "in_progress"},
+	}
+
+	for _, tt := range tests {
+		if got := workflowState(&workflow{Runs: tt.runs}); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDiffStates(t *testing.T) {
+	at := time.Date(2024, 5, 6, 12, 30, 0, 0, time.Local)
+	poll := func(states map[string]string) []*repositoryData {
+		r := &repositoryData{Name: "cli/a"}
+		for _, name := range []string{"CI", "Deploy", "Docs"} {
+			state, ok := states[name]
+			if !ok {
+				continue
+			}
+			w := &workflow{Name: name}
+			switch state {
+			case "none":
+			case "in_progress":
+				w.Runs = []run{{Status: state}}
+			default:
+				w.Runs = []run{{Status: "completed", Conclusion: state}}
+			}
+			r.Workflows = append(r.Workflows, w)
+		}
+		return []*repositoryData{r}
+	}
+
+	polls := []struct {
+		states map[string]string
+		want   []string
+	}{
+		// The first poll only records where things stand.
+		{states: map[string]string{"CI": "success", "Deploy": "none"}},
+		{states: map[string]string{"CI": "success", "Deploy": "none"}},
+		{
+			states: map[string]string{"CI": "failure", "Deploy": "in_progress"},
+			want:   []string{"[12:30] cli/a/CI: success → failure", "[12:30] cli/a/Deploy: none → in_progress"},
+		},
+		// A workflow seen for the first time isn't a transition.
+		{
+			states: map[string]string{"CI": "failure", "Deploy": "success", "Docs": "success"},
+			want:   []string{"[12:30] cli/a/Deploy: in_progress → success"},
+		},
+		{states: map[string]string{"CI": "failure", "Deploy": "success", "Docs": "success"}},
+	}
+
+	prev := map[string]string{}
+	for i, p := range polls {
+		got := diffStates(prev, poll(p.states), at)
+		if strings.Join(got, "\n") != strings.Join(p.want, "\n") {
+			t.Errorf("poll %d: got %q, want %q", i+1, got, p.want)
+		}
+	}
+	if len(prev) != 3 || prev["cli/a/Docs"] != "success" {
+		t.Errorf("got states %v", prev)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/vilmibm/actions-dashboard/util"
+)
+
+var tableHeader = []string{"WORKFLOW", "HEALTH", "AVG ELAPSED", "SUCCESS", "BILLABLE"}
+
+func tableRow(w *workflow, ro renderOptions) []string {
+	success := "-"
+	if successes, total, pct := w.SuccessRate(); total > 0 {
+		success = fmt.Sprintf("%d/%d (%.0f%%)", successes, total, pct)
+	}
+
+	billable := "-"
+	if w.BillableMs > 0 {
+		billable = util.PrettyMS(w.BillableMs)
+	}
+
+	health := w.RenderHealth(ro)
+	if len(w.Runs) == 0 && len(w.InProgress) > 0 {
+		health = fmt.Sprintf("%d running", len(w.InProgress))
+	}
+
+	return []string{
+		w.Name,
+		health,
+		w.AverageElapsed().String(),
+		success,
+		billable,
+	}
+}
+
+// tableSection is a titled block of rows: a repository's workflows, or with
+// --group-by workflow, one workflow's repositories.
+type tableSection struct {
+	Title string
+	Rows  [][]string
+}
+
+// renderTable prints one row per workflow, grouped under a header per
+// repository or, with --group-by workflow, per workflow name. Columns line up
+// across every section.
+func renderTable(out io.Writer, repos []*repositoryData, opts *options, asOf dataFreshness) error {
+	if !opts.Quiet {
+		fmt.Fprintf(out, "GitHub Actions dashboard for %s %s\n", opts.owners(), opts.period())
+		fmt.Fprintln(out, lipgloss.NewStyle().Foreground(colors.Label).Render(asOf.String()))
+	}
+
+	ro := opts.render()
+	header := tableHeader
+	sections := []tableSection{}
+	if opts.GroupBy == groupByWorkflow {
+		header = append([]string{"REPOSITORY"}, tableHeader[1:]...)
+		for _, g := range pivotByWorkflow(repos) {
+			section := tableSection{Title: g.Name}
+			for _, rw := range g.Workflows {
+				row := tableRow(rw.Workflow, ro)
+				row[0] = rw.Repo
+				section.Rows = append(section.Rows, row)
+			}
+			sections = append(sections, section)
+		}
+	} else {
+		for _, r := range repos {
+			if len(r.Workflows) == 0 && !opts.ShowEmpty {
+				continue
+			}
+			section := tableSection{Title: r.Name}
+			for _, w := range r.Workflows {
+				section.Rows = append(section.Rows, tableRow(w, ro))
+			}
+			sections = append(sections, section)
+		}
+	}
+
+	widths := make([]int, len(header))
+	measure := func(row []string) {
+		for i, cell := range row {
+			// Health glyphs carry color codes, so measure printed width.
+			if w := lipgloss.Width(cell); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+
+	measure(header)
+	for _, section := range sections {
+		for _, row := range section.Rows {
+			measure(row)
+		}
+	}
+
+	titleStyle := lipgloss.NewStyle().Bold(colorEnabled)
+	headerStyle := lipgloss.NewStyle().Foreground(colors.Label)
+
+	for i, section := range sections {
+		if !opts.Quiet || i > 0 {
+			fmt.Fprintln(out)
+		}
+		fmt.Fprintln(out, titleStyle.Render(section.Title))
+		if len(section.Rows) == 0 {
+			fmt.Fprintln(out, headerStyle.Render("No workflows"))
+			continue
+		}
+		fmt.Fprintln(out, headerStyle.Render(formatTableRow(header, widths)))
+		for _, row := range section.Rows {
+			fmt.Fprintln(out, formatTableRow(row, widths))
+		}
+	}
+
+	return nil
+}
+
+func formatTableRow(row []string, widths []int) string {
+	cells := make([]string, len(row))
+	for i, cell := range row {
+		cells[i] = cell + strings.Repeat(" ", widths[i]-lipgloss.Width(cell))
+	}
+
+	return strings.TrimRight(strings.Join(cells, "  "), " ")
+}
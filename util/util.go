@@ -26,6 +26,49 @@ func FuzzyAgo(ago time.Duration) string {
 	return Pluralize(int(ago.Hours()/24/365), "year")
 }
 
+// ShortAgo describes how long ago something happened in a few columns, to
+// fit narrow cards, eg "5m", "3h", "2d", "4mo" or "1y".
+func ShortAgo(ago time.Duration) string {
+	if ago < time.Hour {
+		return fmt.Sprintf("%dm", int(ago.Minutes()))
+	}
+	if ago < 24*time.Hour {
+		return fmt.Sprintf("%dh", int(ago.Hours()))
+	}
+	if ago < 30*24*time.Hour {
+		return fmt.Sprintf("%dd", int(ago.Hours())/24)
+	}
+	if ago < 365*24*time.Hour {
+		return fmt.Sprintf("%dmo", int(ago.Hours())/24/30)
+	}
+
+	return fmt.Sprintf("%dy", int(ago.Hours()/24/365))
+}
+
+// durationUnits are the units FuzzyDuration picks from, largest first. Months
+// are left out so that 30 days reads as "30 days" rather than "1 month".
+var durationUnits = []struct {
+	size time.Duration
+	name string
+}{
+	{7 * 24 * time.Hour, "week"},
+	{24 * time.Hour, "day"},
+	{time.Hour, "hour"},
+	{time.Minute, "minute"},
+}
+
+// FuzzyDuration describes the length of a span, eg "30 days" or "2 weeks",
+// using the largest unit it is a whole number of.
+func FuzzyDuration(d time.Duration) string {
+	for _, u := range durationUnits {
+		if d >= u.size && d%u.size == 0 {
+			return Pluralize(int(d/u.size), u.name)
+		}
+	}
+
+	return d.String()
+}
+
 func PrettyMS(ms int) string {
 	if ms == 60000 {
 		return "1m"
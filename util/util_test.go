@@ -0,0 +1,56 @@
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFuzzyDuration(t *testing.T) {
+	day := 24 * time.Hour
+
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{d: time.Hour, want: "1 hour"},
+		{d: 36 * time.Hour, want: "36 hours"},
+		{d: day, want: "1 day"},
+		{d: 30 * day, want: "30 days"},
+		{d: 7 * day, want: "1 week"},
+		{d: 28 * day, want: "4 weeks"},
+		{d: 90 * time.Minute, want: "90 minutes"},
+		{d: 90 * time.Second, want: "1m30s"},
+	}
+
+	for _, tt := range tests {
+		if got := FuzzyDuration(tt.d); got != tt.want {
+			t.Errorf("FuzzyDuration(%s) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestShortAgo(t *testing.T) {
+	day := 24 * time.Hour
+
+	tests := []struct {
+		ago  time.Duration
+		want string
+	}{
+		{ago: 30 * time.Second, want: "0m"},
+		{ago: 59 * time.Minute, want: "59m"},
+		{ago: time.Hour, want: "1h"},
+		{ago: 23*time.Hour + 59*time.Minute, want: "23h"},
+		{ago: day, want: "1d"},
+		{ago: 29 * day, want: "29d"},
+		{ago: 30 * day, want: "1mo"},
+		{ago: 364 * day, want: "12mo"},
+		{ago: 365 * day, want: "1y"},
+		{ago: 3 * 365 * day, want: "3y"},
+	}
+
+	for _, tt := range tests {
+		if got := ShortAgo(tt.ago); got != tt.want {
+			t.Errorf("ShortAgo(%s) = %q, want %q", tt.ago, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package main
+
+import "runtime/debug"
+
+// version is set at build time, eg:
+//
+//	go build -ldflags "-X main.version=v1.2.3"
+var version = ""
+
+// buildVersion reports the version injected via ldflags, falling back to the
+// module version recorded by the Go toolchain.
+func buildVersion() string {
+	if version != "" {
+		return version
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+
+	return "(devel)"
+}
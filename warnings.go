@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// warningLog collects problems that were worked around, such as a skipped
+// repository, so they can be summarized once output is done instead of
+// interleaving with it.
+type warningLog struct {
+	mu       sync.Mutex
+	messages []string
+	seen     map[string]bool
+}
+
+// warnings holds what the current run worked around until it can be printed
+// after the dashboard, or after each poll in --watch and --stream.
+var warnings = &warningLog{}
+
+func (l *warningLog) add(format string, a ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	msg := fmt.Sprintf(format, a...)
+	if l.seen[msg] {
+		return
+	}
+	if l.seen == nil {
+		l.seen = map[string]bool{}
+	}
+	l.seen[msg] = true
+	l.messages = append(l.messages, msg)
+}
+
+// list returns a copy of the warnings collected so far, in order.
+func (l *warningLog) list() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]string{}, l.messages...)
+}
+
+// flush writes every collected warning to out and forgets them.
+func (l *warningLog) flush(out io.Writer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, msg := range l.messages {
+		fmt.Fprintf(out, "warning: %s\n", msg)
+	}
+	l.messages = nil
+	l.seen = nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/muesli/termenv"
+)
+
+// watchDashboard redraws the dashboard every opts.Interval until
+// interrupted. An interrupt also cancels a fetch in progress.
+func watchDashboard(out io.Writer, f Fetcher, opts *options) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	runWatch(ctx, out, f, opts, ticker.C, time.Now)
+
+	return nil
+}
+
+// runWatch draws the dashboard in the terminal's alternate screen now and on
+// every tick, stamped with the time from now, until ctx is done. It then
+// restores the terminal.
+func runWatch(ctx context.Context, out io.Writer, f Fetcher, opts *options, ticks <-chan time.Time, now func() time.Time) {
+	fmt.Fprint(out, termenv.CSI+termenv.AltScreenSeq+termenv.CSI+termenv.HideCursorSeq)
+	defer fmt.Fprint(out, termenv.CSI+termenv.ShowCursorSeq+termenv.CSI+termenv.ExitAltScreenSeq)
+
+	watchLoop(ctx, ticks, func() {
+		drawWatchFrame(ctx, out, f, opts, now())
+	})
+}
+
+// watchLoop draws immediately and then on every tick until ctx is done.
+func watchLoop(ctx context.Context, ticks <-chan time.Time, draw func()) {
+	draw()
+
+	for {
+		select {
+		case <-ticks:
+			draw()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// drawWatchFrame fetches and renders off screen first so that the previous
+// frame stays up while the API is queried. Nothing is drawn once ctx is
+// done, since the watch is over.
+func drawWatchFrame(ctx context.Context, out io.Writer, f Fetcher, opts *options, now time.Time) {
+	var frame bytes.Buffer
+
+	fetchCtx, cancel := opts.fetchContext(ctx)
+	defer cancel()
+
+	repos, err := fetchDashboard(fetchCtx, f, opts)
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(&frame, "%s\n", err)
+	} else {
+		for _, r := range repos {
+			sortWorkflows(r.Workflows, opts.Sort, opts.Reverse)
+		}
+		if err := renderDashboard(&frame, shownRepos(repos, opts), opts, dataFreshness{FetchedAt: now}); err != nil {
+			fmt.Fprintf(&frame, "%s\n", err)
+		}
+	}
+	warnings.flush(&frame)
+	fmt.Fprintf(&frame, "\nUpdated %s; refreshing every %s. Press Ctrl-C to quit.\n", now.Format("15:04:05"), opts.Interval)
+
+	fmt.Fprintf(out, termenv.CSI+termenv.EraseDisplaySeq+termenv.CSI+termenv.CursorPositionSeq, 2, 1, 1)
+	_, _ = frame.WriteTo(out)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/muesli/termenv"
+)
+
+// frameWriter collects everything written to it, passing on each write that
+// finishes a watch frame.
+type frameWriter struct {
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	frames chan string
+}
+
+func (w *frameWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if bytes.Contains(p, []byte("Press Ctrl-C to quit")) {
+		w.frames <- string(p)
+	}
+	return w.buf.Write(p)
+}
+
+func (w *frameWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func (w *frameWriter) next(t *testing.T) string {
+	t.Helper()
+	select {
+	case frame := <-w.frames:
+		return frame
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a frame")
+		return ""
+	}
+}
+
+func TestRunWatch(t *testing.T) {
+	f := newFakeFetcher()
+	f.addRepo("cli/a", false)
+	ci := f.addWorkflow("cli/a", "CI", completedRun("success", time.Hour, time.Minute))
+	opts := testOptions(t, "--watch", "--json", "cli")
+
+	start := time.Date(2024, 5, 6, 12, 0, 0, 0, time.Local)
+	draws := 0
+	now := func() time.Time {
+		draws++
+		return start.Add(time.Duration(draws-1) * time.Second)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ticks := make(chan time.Time)
+	out := &frameWriter{frames: make(chan string, 10)}
+	done := make(chan struct{})
+	go func() {
+		runWatch(ctx, out, f, opts, ticks, now)
+		close(done)
+	}()
+
+	first := out.next(t)
+	if !strings.Contains(first, "Updated 12:00:00; refreshing every 1m0s") || !strings.Contains(first, `"success"`) {
+		t.Errorf("got first frame:\n%s", first)
+	}
+
+	// The frame is written, so nothing is reading the fetcher until the
+	// next tick.
+	f.runs[ci.URL] = []runPayload{completedRun("failure", time.Minute, time.Minute)}
+	ticks <- start
+
+	second := out.next(t)
+	if !strings.Contains(second, "Updated 12:00:01") || !strings.Contains(second, `"failure"`) {
+		t.Errorf("got second frame:\n%s", second)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch didn't stop when cancelled")
+	}
+
+	got := out.String()
+	if n := strings.Count(got, "Press Ctrl-C to quit"); n != 2 {
+		t.Errorf("got %d frames, want 2", n)
+	}
+	if !strings.HasPrefix(got, termenv.CSI+termenv.AltScreenSeq) {
+		t.Error("didn't switch to the alternate screen")
+	}
+	if !strings.HasSuffix(got, termenv.CSI+termenv.ShowCursorSeq+termenv.CSI+termenv.ExitAltScreenSeq) {
+		t.Error("didn't restore the terminal")
+	}
+}